@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dhamidi/kratt/worker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resumeWait         bool
+	resumePollInterval time.Duration
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <run-id>",
+	Short: "Push the changes of a run awaiting approval",
+	Long:  "Pushes the locally committed changes of a run started with --require-approval.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runApprove,
+}
+
+var rejectCmd = &cobra.Command{
+	Use:   "reject <run-id>",
+	Short: "Discard the changes of a run awaiting approval",
+	Long:  "Resets the worktree of a run started with --require-approval, discarding its local commit.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runReject,
+}
+
+var workerResumeCmd = &cobra.Command{
+	Use:   "resume <run-id>",
+	Short: "Apply an approval decision posted on the pull request",
+	Long:  "Looks for a /kratt approve or /kratt reject comment from a repository owner, member or collaborator on the run's pull request and pushes or discards the run's changes accordingly.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkerResume,
+}
+
+func init() {
+	workerResumeCmd.Flags().BoolVar(&resumeWait, "wait", false, "Keep polling the pull request until a decision is posted")
+	workerResumeCmd.Flags().DurationVar(&resumePollInterval, "poll-interval", time.Minute, "Time between checks for a decision with --wait")
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(rejectCmd)
+	workerCmd.AddCommand(workerResumeCmd)
+}
+
+// newApprovalWorker returns a worker that can resume the run from run history;
+// approved plans are executed as implementation tasks
+func newApprovalWorker(runID string) (*worker.Worker, error) {
+	w, err := newPRWorker(worker.TaskImplement, defaultImplementInstructions)
+	if err != nil {
+		return nil, err
+	}
+	if err := useRunRepository(w, runID); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func runApprove(cmd *cobra.Command, args []string) error {
+	w, err := newApprovalWorker(args[0])
+	if err != nil {
+		return err
+	}
+	if err := w.ApproveRun(cmd.Context(), args[0], ""); err != nil {
+		return fmt.Errorf("failed to approve run %s: %w", args[0], err)
+	}
+	if verbose {
+		fmt.Printf("Pushed changes of run %s\n", args[0])
+	}
+	return nil
+}
+
+func runReject(cmd *cobra.Command, args []string) error {
+	w, err := newApprovalWorker(args[0])
+	if err != nil {
+		return err
+	}
+	if err := w.RejectRun(cmd.Context(), args[0], ""); err != nil {
+		return fmt.Errorf("failed to reject run %s: %w", args[0], err)
+	}
+	if verbose {
+		fmt.Printf("Discarded changes of run %s\n", args[0])
+	}
+	return nil
+}
+
+func runWorkerResume(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	w, err := newApprovalWorker(args[0])
+	if err != nil {
+		return err
+	}
+
+	for {
+		decided, err := w.ResumeRun(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to resume run %s: %w", args[0], err)
+		}
+		if decided {
+			if verbose {
+				fmt.Printf("Applied decision for run %s\n", args[0])
+			}
+			return nil
+		}
+		if !resumeWait {
+			fmt.Printf("Run %s is still awaiting approval\n", args[0])
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(resumePollInterval):
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dhamidi/kratt/worker"
+)
+
+// defaultConfigFile is loaded from the current directory when --config is not given
+const defaultConfigFile = ".kratt.json"
+
+// fileConfig is the on-disk kratt configuration
+type fileConfig struct {
+	Env        []string          `json:"env"`
+	Agents     []agentConfig     `json:"agents"`
+	Routes     []routeConfig     `json:"routes"`
+	Sandbox    *sandboxConfig    `json:"sandbox"`
+	Policy     *policyConfig     `json:"policy"`
+	SecretScan *secretScanConfig `json:"secret_scan"`
+	Worktrees  *worktreesConfig  `json:"worktrees"`
+	Git        *gitConfig        `json:"git"`
+	Commit     *commitConfig     `json:"commit"`
+}
+
+// agentConfig describes one agent profile
+type agentConfig struct {
+	Name    string   `json:"name"`
+	Mode    string   `json:"mode"`
+	Command []string `json:"command"`
+	Timeout string   `json:"timeout"`
+}
+
+// routeConfig describes one agent routing rule
+type routeConfig struct {
+	Label        string   `json:"label"`
+	Task         string   `json:"task"`
+	MinDiffLines int      `json:"min_diff_lines"`
+	MaxDiffLines int      `json:"max_diff_lines"`
+	Agents       []string `json:"agents"`
+}
+
+// sandboxConfig describes the isolated environment agents run in
+type sandboxConfig struct {
+	Backend   string   `json:"backend"`
+	Image     string   `json:"image"`
+	Env       []string `json:"env"`
+	ReadOnly  []string `json:"read_only"`
+	Writable  []string `json:"writable"`
+	NoNetwork bool     `json:"no_network"`
+	Memory    string   `json:"memory"`
+	CPUs      string   `json:"cpus"`
+	Pids      int      `json:"pids"`
+	Tests     bool     `json:"tests"`
+}
+
+// policyConfig describes the changes the worker may commit
+type policyConfig struct {
+	ProtectedPaths          []string `json:"protected_paths"`
+	MaxFiles                int      `json:"max_files"`
+	MaxLines                int      `json:"max_lines"`
+	ForbiddenDeletions      []string `json:"forbidden_deletions"`
+	ForbidTestSkips         bool     `json:"forbid_test_skips"`
+	ForbidBinary            bool     `json:"forbid_binary"`
+	ForbidReplaceDirectives bool     `json:"forbid_replace_directives"`
+	CommitMessagePattern    string   `json:"commit_message_pattern"`
+}
+
+// secretScanConfig tunes the secret scan run before pushing
+type secretScanConfig struct {
+	Disabled         bool     `json:"disabled"`
+	EntropyThreshold float64  `json:"entropy_threshold"`
+	Ignore           []string `json:"ignore"`
+}
+
+// worktreesConfig controls where PR worktrees live and how they are reused
+type worktreesConfig struct {
+	Root  string `json:"root"`
+	Reset bool   `json:"reset"`
+	Dirty string `json:"dirty"`
+}
+
+// gitConfig selects the implementation of git operations
+type gitConfig struct {
+	Backend string `json:"backend"`
+}
+
+// commitConfig controls the messages of commits made for the agent
+type commitConfig struct {
+	Template      string          `json:"template"`
+	SummarizeDiff bool            `json:"summarize_diff"`
+	Author        *identityConfig `json:"author"`
+	Committer     *identityConfig `json:"committer"`
+	Signing       *signingConfig  `json:"signing"`
+	SignOff       bool            `json:"sign_off"`
+	CoAuthor      bool            `json:"co_author"`
+	AgentCommits  string          `json:"agent_commits"`
+}
+
+// identityConfig is a git author or committer
+type identityConfig struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// signingConfig selects how commits are signed
+type signingConfig struct {
+	Format string `json:"format"`
+	Key    string `json:"key"`
+}
+
+// Git backends selectable in the configuration
+const (
+	gitBackendExec  = "exec"
+	gitBackendGoGit = "go-git"
+)
+
+// loadConfig reads the configuration file; a missing default file yields an empty configuration
+func loadConfig(path string) (*fileConfig, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return &fileConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config fileConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// applyConfig configures the worker's agent profiles, routes, sandbox, policy,
+// worktree reset and secret scan from the configuration
+func applyConfig(w *worker.Worker, config *fileConfig) error {
+	for _, a := range config.Agents {
+		if a.Name == "" {
+			return fmt.Errorf("agent profile without name in config")
+		}
+
+		agent, err := worker.NewAgent(a.Mode, a.Command)
+		if err != nil {
+			return fmt.Errorf("invalid agent profile %s: %w", a.Name, err)
+		}
+
+		var agentTimeout time.Duration
+		if a.Timeout != "" {
+			agentTimeout, err = time.ParseDuration(a.Timeout)
+			if err != nil {
+				return fmt.Errorf("invalid timeout for agent profile %s: %w", a.Name, err)
+			}
+		}
+
+		w.Agents = append(w.Agents, worker.AgentProfile{Name: a.Name, Agent: agent, Timeout: agentTimeout})
+	}
+
+	for _, r := range config.Routes {
+		if len(r.Agents) == 0 {
+			return fmt.Errorf("route without agents in config")
+		}
+		w.Routes = append(w.Routes, worker.AgentRoute{
+			Label:        r.Label,
+			TaskType:     r.Task,
+			MinDiffLines: r.MinDiffLines,
+			MaxDiffLines: r.MaxDiffLines,
+			Agents:       r.Agents,
+		})
+	}
+
+	if config.Sandbox != nil {
+		sandboxEnv := config.Sandbox.Env
+		if len(sandboxEnv) == 0 {
+			sandboxEnv = config.envAllowlist()
+		}
+		sandbox := worker.SandboxConfig{
+			Backend:   config.Sandbox.Backend,
+			Image:     config.Sandbox.Image,
+			Env:       sandboxEnv,
+			ReadOnly:  config.Sandbox.ReadOnly,
+			Writable:  config.Sandbox.Writable,
+			NoNetwork: config.Sandbox.NoNetwork,
+			Memory:    config.Sandbox.Memory,
+			CPUs:      config.Sandbox.CPUs,
+			Pids:      config.Sandbox.Pids,
+		}
+		if err := sandbox.Validate(); err != nil {
+			return fmt.Errorf("invalid sandbox config: %w", err)
+		}
+
+		w.AgentRunner = &worker.SandboxRunner{Config: sandbox, Runner: w.Runner}
+		if config.Sandbox.Tests {
+			w.CheckRunner = w.AgentRunner
+		}
+	}
+
+	w.Policy = worker.DefaultPolicy()
+	if config.Policy != nil {
+		w.Policy = &worker.Policy{
+			ProtectedPaths:          config.Policy.ProtectedPaths,
+			MaxFiles:                config.Policy.MaxFiles,
+			MaxLines:                config.Policy.MaxLines,
+			ForbiddenDeletions:      config.Policy.ForbiddenDeletions,
+			ForbidTestSkips:         config.Policy.ForbidTestSkips,
+			ForbidBinary:            config.Policy.ForbidBinary,
+			ForbidReplaceDirectives: config.Policy.ForbidReplaceDirectives,
+		}
+		if config.Policy.CommitMessagePattern != "" {
+			pattern, err := regexp.Compile(config.Policy.CommitMessagePattern)
+			if err != nil {
+				return fmt.Errorf("invalid commit message pattern: %w", err)
+			}
+			w.Policy.CommitMessagePattern = pattern
+		}
+	}
+
+	if config.Worktrees != nil {
+		w.ResetWorktrees = config.Worktrees.Reset
+		switch config.Worktrees.Dirty {
+		case "", worker.DirtyAbort, worker.DirtyStash, worker.DirtyReset:
+			w.DirtyWorktree = config.Worktrees.Dirty
+		default:
+			return fmt.Errorf("invalid dirty worktree policy %q: must be abort, stash or reset", config.Worktrees.Dirty)
+		}
+	}
+
+	if config.Commit != nil {
+		w.SummarizeDiff = config.Commit.SummarizeDiff
+		switch config.Commit.AgentCommits {
+		case "", worker.AgentCommitsPreserve, worker.AgentCommitsSquash:
+			w.AgentCommits = config.Commit.AgentCommits
+		default:
+			return fmt.Errorf("invalid agent commits policy %q: must be preserve or squash", config.Commit.AgentCommits)
+		}
+		if config.Commit.Template != "" {
+			tmpl, err := worker.ParseCommitTemplate(config.Commit.Template)
+			if err != nil {
+				return err
+			}
+			w.CommitTemplate = tmpl
+		}
+	}
+
+	w.SecretScanner = &worker.SecretScanner{Known: worker.SecretEnvValues()}
+	if config.SecretScan != nil {
+		if config.SecretScan.Disabled {
+			w.SecretScanner = nil
+		} else {
+			w.SecretScanner.EntropyThreshold = config.SecretScan.EntropyThreshold
+			w.SecretScanner.Ignore = config.SecretScan.Ignore
+		}
+	}
+
+	return nil
+}
+
+// envAllowlist returns the environment variables passed to agent, lint and
+// test commands: worker.DefaultEnvAllowlist extended by the configured env
+func (c *fileConfig) envAllowlist() []string {
+	return append(append([]string{}, worker.DefaultEnvAllowlist...), c.Env...)
+}
+
+// newExecRunner returns the runner for agent, lint and test commands
+func newExecRunner(config *fileConfig) *worker.ExecRunner {
+	return &worker.ExecRunner{GracePeriod: killGrace, Env: config.envAllowlist()}
+}
+
+// newLocalGit returns the git client of the configured backend, placing new
+// worktrees in the configured root
+func newLocalGit(config *fileConfig) (worker.LocalGit, error) {
+	root, err := worktreeRoot(config)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := commitSettings(config)
+	if err != nil {
+		return nil, err
+	}
+	runner := &worker.GitRunner{WorktreeRoot: root, Commits: commits}
+
+	backend := gitBackendExec
+	if config.Git != nil && config.Git.Backend != "" {
+		backend = config.Git.Backend
+	}
+	switch backend {
+	case gitBackendExec:
+		return runner, nil
+	case gitBackendGoGit:
+		return &worker.GoGit{Fallback: runner, WorktreeRoot: root, Commits: commits}, nil
+	default:
+		return nil, fmt.Errorf("invalid git backend %q: must be %s or %s", backend, gitBackendExec, gitBackendGoGit)
+	}
+}
+
+// commitSettings returns the configured identity, signing and sign-off of commits
+func commitSettings(config *fileConfig) (worker.CommitSettings, error) {
+	settings := worker.CommitSettings{}
+	if config.Commit == nil {
+		return settings, nil
+	}
+	settings.SignOff = config.Commit.SignOff
+	if author := config.Commit.Author; author != nil {
+		settings.AuthorName, settings.AuthorEmail = author.Name, author.Email
+	}
+	if committer := config.Commit.Committer; committer != nil {
+		settings.CommitterName, settings.CommitterEmail = committer.Name, committer.Email
+	}
+	if signing := config.Commit.Signing; signing != nil {
+		settings.SigningFormat, settings.SigningKey = signing.Format, signing.Key
+		if settings.SigningFormat == worker.SigningSSH {
+			key, err := expandHome(settings.SigningKey)
+			if err != nil {
+				return settings, fmt.Errorf("failed to expand signing key path: %w", err)
+			}
+			settings.SigningKey = key
+		}
+	}
+	if err := settings.Validate(); err != nil {
+		return settings, fmt.Errorf("invalid commit config: %w", err)
+	}
+	return settings, nil
+}
+
+// worktreeRoot returns the absolute configured worktree root, or "" if none is configured
+func worktreeRoot(config *fileConfig) (string, error) {
+	if config.Worktrees == nil || config.Worktrees.Root == "" {
+		return "", nil
+	}
+
+	root, err := expandHome(config.Worktrees.Root)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand worktree root: %w", err)
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve worktree root: %w", err)
+	}
+	return root, nil
+}
+
+// newGitHub returns the GitHub client for repo, or for the current repository
+// if repo is empty; secrets are redacted from everything it posts
+func newGitHub(repo string) worker.GitHub {
+	return &worker.RedactingGitHub{
+		GitHub:   &worker.GitHubCLI{Repo: repo},
+		Redactor: newRedactor(),
+	}
+}
+
+// useRunRepository points the worker's GitHub client at the repository the
+// run was recorded for, so that runs of owner/repo#pr-number are not acted
+// on in the repository of the current directory
+func useRunRepository(w *worker.Worker, runID string) error {
+	run, err := w.Runs.GetRun(runID)
+	if err != nil {
+		return err
+	}
+	if run.Repo != "" {
+		w.GitHub = newGitHub(run.Repo)
+	}
+	return nil
+}
+
+// newRedactor returns a Redactor masking the secrets in the environment
+func newRedactor() *worker.Redactor {
+	return worker.NewRedactor(worker.SecretEnvValues()...)
+}
+
+// newRunStore returns the run history store in the default location
+func newRunStore() (worker.RunStore, error) {
+	dir, err := worker.DefaultRunDir()
+	if err != nil {
+		return nil, err
+	}
+	return &worker.FileRunStore{Dir: dir}, nil
+}
+
+// expandHome replaces a leading "~/" in a path with the home directory
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dhamidi/kratt/worker"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kratt.json")
+	content := `{
+		"agents": [
+			{"name": "claude", "mode": "jsonl", "command": ["claude", "-p"], "timeout": "20m"},
+			{"name": "amp", "command": ["amp", "--stdin"]}
+		],
+		"routes": [
+			{"label": "docs", "agents": ["amp"]}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	w := &worker.Worker{}
+	if err := applyConfig(w, config); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+
+	if len(w.Agents) != 2 || w.Agents[0].Name != "claude" || w.Agents[0].Timeout != 20*time.Minute {
+		t.Errorf("Unexpected agent profiles: %+v", w.Agents)
+	}
+	if len(w.Routes) != 1 || w.Routes[0].Label != "docs" {
+		t.Errorf("Unexpected routes: %+v", w.Routes)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing explicit config file")
+	}
+}
+
+func TestApplyConfigSandbox(t *testing.T) {
+	config := &fileConfig{Sandbox: &sandboxConfig{Backend: "docker", Image: "golang:1.22", Tests: true}}
+
+	w := &worker.Worker{Runner: worker.NewFakeCommandRunner()}
+	if err := applyConfig(w, config); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if _, ok := w.AgentRunner.(*worker.SandboxRunner); !ok {
+		t.Errorf("Expected agent to run in sandbox, got %T", w.AgentRunner)
+	}
+	if w.CheckRunner != w.AgentRunner {
+		t.Error("Expected tests to run in sandbox")
+	}
+
+	config.Sandbox.Image = ""
+	if err := applyConfig(&worker.Worker{}, config); err == nil {
+		t.Error("Expected error for container sandbox without image")
+	}
+}
+
+func TestApplyConfigCommit(t *testing.T) {
+	config := &fileConfig{Commit: &commitConfig{Template: "{{.Type}}: {{.Subject}}", SummarizeDiff: true}}
+
+	w := &worker.Worker{}
+	if err := applyConfig(w, config); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if w.CommitTemplate == nil || !w.SummarizeDiff {
+		t.Errorf("Expected commit template and diff summaries, got %v, %v", w.CommitTemplate, w.SummarizeDiff)
+	}
+
+	config.Commit.Template = "{{.Type"
+	if err := applyConfig(&worker.Worker{}, config); err == nil {
+		t.Error("Expected error for invalid commit template")
+	}
+
+	config.Commit = &commitConfig{AgentCommits: "rebase"}
+	if err := applyConfig(&worker.Worker{}, config); err == nil {
+		t.Error("Expected error for invalid agent commits policy")
+	}
+
+	config = &fileConfig{Commit: &commitConfig{AgentCommits: worker.AgentCommitsSquash}, Policy: &policyConfig{CommitMessagePattern: `^(feat|fix): `}}
+	w = &worker.Worker{}
+	if err := applyConfig(w, config); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if w.AgentCommits != worker.AgentCommitsSquash || !w.Policy.CommitMessagePattern.MatchString("fix: parser") {
+		t.Errorf("Unexpected agent commits %q and policy %+v", w.AgentCommits, w.Policy)
+	}
+
+	config.Policy.CommitMessagePattern = "("
+	if err := applyConfig(&worker.Worker{}, config); err == nil {
+		t.Error("Expected error for invalid commit message pattern")
+	}
+}
+
+func TestCommitSettings(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	config := &fileConfig{Commit: &commitConfig{
+		Author:  &identityConfig{Name: "kratt[bot]", Email: "bot@example.com"},
+		Signing: &signingConfig{Format: "ssh", Key: "~/.ssh/kratt"},
+		SignOff: true,
+	}}
+	settings, err := commitSettings(config)
+	if err != nil {
+		t.Fatalf("commitSettings failed: %v", err)
+	}
+	if settings.AuthorName != "kratt[bot]" || settings.SigningKey != filepath.Join(home, ".ssh/kratt") || !settings.SignOff {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+
+	config.Commit.Signing = &signingConfig{Format: "gpg"}
+	if _, err := newLocalGit(config); err == nil {
+		t.Error("Expected error for gpg signing without key")
+	}
+}
+
+func TestNewLocalGitBackend(t *testing.T) {
+	config := &fileConfig{Git: &gitConfig{Backend: "go-git"}, Worktrees: &worktreesConfig{Root: "/srv/worktrees"}}
+	git, err := newLocalGit(config)
+	if err != nil {
+		t.Fatalf("newLocalGit failed: %v", err)
+	}
+	goGit, ok := git.(*worker.GoGit)
+	if !ok || goGit.WorktreeRoot != "/srv/worktrees" {
+		t.Fatalf("Expected go-git backend with worktree root, got %#v", git)
+	}
+	if runner, ok := goGit.Fallback.(*worker.GitRunner); !ok || runner.WorktreeRoot != "/srv/worktrees" {
+		t.Errorf("Expected git fallback with worktree root, got %#v", goGit.Fallback)
+	}
+
+	if git, _ := newLocalGit(&fileConfig{}); git == nil {
+		t.Error("Expected exec backend by default")
+	} else if _, ok := git.(*worker.GitRunner); !ok {
+		t.Errorf("Expected exec backend by default, got %T", git)
+	}
+
+	config.Git.Backend = "libgit2"
+	if _, err := newLocalGit(config); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+func TestConfigEnvAllowlist(t *testing.T) {
+	if env := (&fileConfig{}).envAllowlist(); !reflect.DeepEqual(env, worker.DefaultEnvAllowlist) {
+		t.Errorf("Expected default allowlist without env, got %v", env)
+	}
+
+	env := (&fileConfig{Env: []string{"ANTHROPIC_API_KEY"}}).envAllowlist()
+	if env[0] != "PATH" || env[len(env)-1] != "ANTHROPIC_API_KEY" {
+		t.Errorf("Expected defaults plus configured variables, got %v", env)
+	}
+}
+
+func TestUseRunRepository(t *testing.T) {
+	runs := worker.NewFakeRunStore()
+	runs.SaveRun(&worker.RunRecord{ID: "local", PRNumber: 1})
+	runs.SaveRun(&worker.RunRecord{ID: "remote", Repo: "acme/widgets", PRNumber: 42})
+	local := worker.NewFakeGitHub()
+	w := &worker.Worker{Runs: runs, GitHub: local}
+
+	if err := useRunRepository(w, "local"); err != nil || w.GitHub != local {
+		t.Errorf("Expected the GitHub client of a local run to be kept, got %v", err)
+	}
+	if err := useRunRepository(w, "remote"); err != nil {
+		t.Fatalf("useRunRepository failed: %v", err)
+	}
+	redacting, ok := w.GitHub.(*worker.RedactingGitHub)
+	if cli, isCLI := redacting.GitHub.(*worker.GitHubCLI); !ok || !isCLI || cli.Repo != "acme/widgets" {
+		t.Errorf("Expected a GitHub client for acme/widgets, got %#v", w.GitHub)
+	}
+}
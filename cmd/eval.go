@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dhamidi/kratt/worker"
+	"github.com/spf13/cobra"
+)
+
+var evalOutputDir string
+
+var evalCmd = &cobra.Command{
+	Use:   "eval <fixtures-dir>",
+	Short: "Evaluate the configured agent pipeline against fixture tasks",
+	Long:  "Runs the worker pipeline against each fixture task in a temporary local repository with a fake forge and writes a scored report in JSON and Markdown.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEval,
+}
+
+func init() {
+	evalCmd.Flags().StringVar(&evalOutputDir, "out", "eval-results", "Directory to write report.json and report.md to")
+	rootCmd.AddCommand(evalCmd)
+}
+
+func runEval(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	fixturesDir, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid fixtures directory: %w", err)
+	}
+
+	tasks, err := worker.LoadEvalTasks(fixturesDir)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("no tasks found in %s", fixturesDir)
+	}
+
+	outputDir, err := filepath.Abs(evalOutputDir)
+	if err != nil {
+		return fmt.Errorf("invalid output directory: %w", err)
+	}
+
+	// Load custom instructions if specified
+	instructionsText := "You are an AI assistant helping with implementation. Please analyze the instructions in the pull request description and implement the requested change."
+	if instructions != "" {
+		content, err := os.ReadFile(instructions)
+		if err != nil {
+			return fmt.Errorf("failed to read instructions file: %w", err)
+		}
+		instructionsText = string(content)
+	}
+
+	agent, err := worker.NewAgent(agentMode, agentCommand)
+	if err != nil {
+		return fmt.Errorf("invalid agent configuration: %w", err)
+	}
+
+	config, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	w := &worker.Worker{
+		Instructions:   instructionsText,
+		AgentCommand:   agentCommand,
+		LintCommand:    lintCommand,
+		TestCommand:    testCommand,
+		Deadline:       timeout,
+		Timeouts:       phaseTimeouts(),
+		Agent:          agent,
+		ResultProtocol: agentResult,
+		TaskType:       worker.TaskImplement,
+		Runner:         newExecRunner(config),
+	}
+
+	if err := applyConfig(w, config); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if verbose {
+		fmt.Printf("Evaluating %d tasks from %s\n", len(tasks), fixturesDir)
+	}
+
+	evaluator := &worker.Evaluator{Worker: w}
+	report, err := evaluator.Evaluate(ctx, tasks)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate tasks: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "report.json"), reportJSON, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+
+	markdown := worker.FormatEvalReportMarkdown(report)
+	if err := os.WriteFile(filepath.Join(outputDir, "report.md"), []byte(markdown), 0644); err != nil {
+		return fmt.Errorf("failed to write Markdown report: %w", err)
+	}
+
+	fmt.Print(markdown)
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dhamidi/kratt/worker"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestTask is one entry of a batch start manifest
+type ManifestTask struct {
+	Branch       string   `yaml:"branch"`
+	Instructions string   `yaml:"instructions"`
+	Title        string   `yaml:"title"`
+	Base         string   `yaml:"base"`
+	Draft        bool     `yaml:"draft"`
+	Labels       []string `yaml:"labels"`
+	Reviewers    []string `yaml:"reviewers"`
+	Assignees    []string `yaml:"assignees"`
+	Milestone    string   `yaml:"milestone"`
+}
+
+// Manifest lists the tasks created by kratt worker start --from
+type Manifest struct {
+	Tasks []ManifestTask `yaml:"tasks"`
+}
+
+// markdownTaskPattern matches a Markdown list item such as "- feature/auth: Implement JWT authentication"
+var markdownTaskPattern = regexp.MustCompile(`^[-*] +([^\s:]+): *(.*)$`)
+
+// loadManifest reads a YAML manifest, or a Markdown list if the file ends in .md
+func loadManifest(path string) ([]worker.StartTask, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		manifest = parseMarkdownManifest(string(content))
+	default:
+		if err := yaml.Unmarshal(content, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+		}
+	}
+
+	tasks := make([]worker.StartTask, len(manifest.Tasks))
+	seen := make(map[string]bool)
+	for i, task := range manifest.Tasks {
+		if !isValidBranchName(task.Branch) {
+			return nil, fmt.Errorf("task %d: invalid branch name %q", i+1, task.Branch)
+		}
+		if strings.TrimSpace(task.Instructions) == "" {
+			return nil, fmt.Errorf("task %d (%s): missing instructions", i+1, task.Branch)
+		}
+		if seen[task.Branch] {
+			return nil, fmt.Errorf("task %d: duplicate branch %s", i+1, task.Branch)
+		}
+		seen[task.Branch] = true
+
+		tasks[i] = worker.StartTask{
+			Branch:       task.Branch,
+			Instructions: task.Instructions,
+			Title:        task.Title,
+			PROptions: worker.PROptions{
+				Base:      task.Base,
+				Draft:     task.Draft,
+				Labels:    task.Labels,
+				Reviewers: task.Reviewers,
+				Assignees: task.Assignees,
+				Milestone: task.Milestone,
+			},
+		}
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("manifest %s contains no tasks", path)
+	}
+	return tasks, nil
+}
+
+// parseMarkdownManifest reads "- branch: instructions" list items. Indented
+// lines following an item continue its instructions.
+func parseMarkdownManifest(content string) Manifest {
+	var manifest Manifest
+	var current *ManifestTask
+	for _, line := range strings.Split(content, "\n") {
+		if m := markdownTaskPattern.FindStringSubmatch(line); m != nil {
+			manifest.Tasks = append(manifest.Tasks, ManifestTask{Branch: m[1], Instructions: m[2]})
+			current = &manifest.Tasks[len(manifest.Tasks)-1]
+		} else if current != nil && strings.HasPrefix(line, " ") && strings.TrimSpace(line) != "" {
+			current.Instructions += "\n" + strings.TrimSpace(line)
+		} else if strings.TrimSpace(line) != "" {
+			current = nil
+		}
+	}
+	return manifest
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadManifestYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	content := `tasks:
+  - branch: feature/auth
+    instructions: |
+      Implement JWT authentication
+    base: develop
+    labels: [enhancement]
+    assignees: [alice, bob]
+  - branch: bugfix/login
+    instructions: Fix login validation
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Base != "develop" || len(tasks[0].Assignees) != 2 || tasks[1].Instructions != "Fix login validation" {
+		t.Errorf("Unexpected tasks: %+v", tasks)
+	}
+}
+
+func TestLoadManifestMarkdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.md")
+	content := "# Tasks\n\n- feature/auth: Implement JWT authentication\n  using the existing session store\n- bugfix/login: Fix login validation\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Instructions != "Implement JWT authentication\nusing the existing session store" || tasks[1].Branch != "bugfix/login" {
+		t.Errorf("Unexpected tasks: %+v", tasks)
+	}
+}
+
+func TestLoadManifestInvalid(t *testing.T) {
+	tests := map[string]string{
+		"invalid branch":  "tasks:\n  - branch: bad branch\n    instructions: x\n",
+		"no instructions": "tasks:\n  - branch: feature\n",
+		"duplicate":       "tasks:\n  - branch: a\n    instructions: x\n  - branch: a\n    instructions: y\n",
+		"empty":           "tasks: []\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.yaml")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadManifest(path); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/dhamidi/kratt/worker"
 	"github.com/spf13/cobra"
 )
 
@@ -10,9 +15,20 @@ var (
 	timeout      time.Duration
 	instructions string
 	agentCommand []string
+	agentMode    string
+	agentResult  bool
 	lintCommand  []string
 	testCommand  []string
 	verbose      bool
+	configFile   string
+	coAuthor     string
+
+	setupTimeout time.Duration
+	agentTimeout time.Duration
+	lintTimeout  time.Duration
+	testTimeout  time.Duration
+	pushTimeout  time.Duration
+	killGrace    time.Duration
 )
 
 var rootCmd = &cobra.Command{
@@ -21,15 +37,40 @@ var rootCmd = &cobra.Command{
 	Long:  "Kratt provides a command-line interface for running automated PR processing with AI agents.",
 }
 
+// phaseTimeouts returns the per-phase timeouts configured by flags
+func phaseTimeouts() worker.PhaseTimeouts {
+	return worker.PhaseTimeouts{
+		Setup: setupTimeout,
+		Agent: agentTimeout,
+		Lint:  lintTimeout,
+		Test:  testTimeout,
+		Push:  pushTimeout,
+	}
+}
+
+// Execute runs the root command; SIGINT and SIGTERM cancel the command's context
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
 	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Minute, "Maximum time for agent execution")
+	rootCmd.PersistentFlags().DurationVar(&setupTimeout, "setup-timeout", 0, "Maximum time for fetching the PR and preparing the worktree (default: --timeout)")
+	rootCmd.PersistentFlags().DurationVar(&agentTimeout, "agent-timeout", 0, "Maximum time for the agent phase (default: --timeout)")
+	rootCmd.PersistentFlags().DurationVar(&lintTimeout, "lint-timeout", 0, "Maximum time for the lint command (default: --timeout)")
+	rootCmd.PersistentFlags().DurationVar(&testTimeout, "test-timeout", 0, "Maximum time for the test command (default: --timeout)")
+	rootCmd.PersistentFlags().DurationVar(&pushTimeout, "push-timeout", 0, "Maximum time for committing and pushing (default: --timeout)")
+	rootCmd.PersistentFlags().DurationVar(&killGrace, "kill-grace", worker.DefaultGracePeriod, "Time between SIGTERM and SIGKILL when a command times out")
 	rootCmd.PersistentFlags().StringVar(&instructions, "instructions", "", "Path to file containing agent instructions")
 	rootCmd.PersistentFlags().StringSliceVar(&agentCommand, "agent", []string{"amp", "--stdin"}, "Command to run the AI agent")
+	rootCmd.PersistentFlags().StringVar(&agentMode, "agent-mode", worker.AgentModeStdin, "How the prompt is passed to the agent: stdin, prompt-file or jsonl")
+	rootCmd.PersistentFlags().BoolVar(&agentResult, "agent-result", false, "Ask the agent to write a structured result file rendered into the PR comment")
 	rootCmd.PersistentFlags().StringSliceVar(&lintCommand, "lint", []string{"go", "fmt", "./..."}, "Command to run linting")
 	rootCmd.PersistentFlags().StringSliceVar(&testCommand, "test", []string{"go", "test", "./..."}, "Command to run tests")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to the configuration file (default: .kratt.json if present)")
+	rootCmd.PersistentFlags().StringVar(&coAuthor, "co-author", "", "Credit \"Name <email>\" with a Co-authored-by trailer on agent commits (default with commit.co_author: your git user)")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable verbose output")
 }
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/dhamidi/kratt/worker"
+	"github.com/spf13/cobra"
+)
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "Manage recorded worker runs",
+	Long:  "Acts on the runs recorded in ~/.kratt/runs.",
+}
+
+var runsRevertCmd = &cobra.Command{
+	Use:   "revert <run-id>",
+	Short: "Undo the pushed changes of a run",
+	Long:  "Pushes a commit reverting the changes of a run, found by the starting and last commits recorded for it, and notes on the pull request who reverted them. With --reset, the branch is reset to the run's starting commit and force-pushed with lease instead, which is refused if anything was pushed after the run.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRunsRevert,
+}
+
+var (
+	revertReset bool
+	revertYes   bool
+)
+
+func init() {
+	runsRevertCmd.Flags().BoolVar(&revertReset, "reset", false, "Reset the branch to the run's starting commit and force-push with lease instead of pushing a revert commit")
+	runsRevertCmd.Flags().BoolVar(&revertYes, "yes", false, "Do not ask for confirmation before resetting with --reset")
+	runsCmd.AddCommand(runsRevertCmd)
+	rootCmd.AddCommand(runsCmd)
+}
+
+func runRunsRevert(cmd *cobra.Command, args []string) error {
+	w, err := newPRWorker(worker.TaskReview, defaultReviewInstructions)
+	if err != nil {
+		return err
+	}
+	if err := useRunRepository(w, args[0]); err != nil {
+		return err
+	}
+
+	if revertReset && !revertYes {
+		run, err := w.Runs.GetRun(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "This resets %s to %s and force-pushes it, discarding commits %s..%s.\nType the branch name to confirm: ",
+			run.Branch, shortHead(run.BaseSHA), shortHead(run.BaseSHA), shortHead(run.CommitSHA))
+		answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if strings.TrimSpace(answer) != run.Branch || run.Branch == "" {
+			return fmt.Errorf("reset of run %s not confirmed", run.ID)
+		}
+	}
+
+	if err := w.RevertRun(cmd.Context(), args[0], revertReset); err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Printf("Reverted run %s\n", args[0])
+	}
+	return nil
+}
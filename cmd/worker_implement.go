@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dhamidi/kratt/worker"
+	"github.com/spf13/cobra"
+)
+
+var workerImplementCmd = &cobra.Command{
+	Use:   "implement <pr-number>",
+	Short: "Work through the open steps of a pull request's implementation status file",
+	Long:  "Runs the agent once per open step of the branch's implementation status file, verifying each step with lint and tests and committing it separately. Stops when all steps are done, a step fails verification or disappears from the status file, or the budget is used up; an unfinished step is not pushed.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkerImplement,
+}
+
+var (
+	maxSteps    int
+	stepsBudget time.Duration
+)
+
+func init() {
+	workerImplementCmd.Flags().IntVar(&maxSteps, "max-steps", worker.DefaultPlanMaxSteps, "Maximum number of steps to complete (0 means no limit)")
+	workerImplementCmd.Flags().DurationVar(&stepsBudget, "budget", 0, "Do not start another step after this much time (0 means no limit)")
+	workerCmd.AddCommand(workerImplementCmd)
+}
+
+func runWorkerImplement(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	prNumber, err := strconv.Atoi(args[0])
+	if err != nil || prNumber <= 0 {
+		return fmt.Errorf("invalid pull request number: must be a positive integer")
+	}
+
+	w, err := newPRWorker(worker.TaskImplement, defaultImplementInstructions)
+	if err != nil {
+		return err
+	}
+	w.Budget = worker.PlanBudget{MaxSteps: maxSteps, MaxDuration: stepsBudget}
+
+	if err := w.ImplementPR(ctx, prNumber); err != nil {
+		return fmt.Errorf("failed to implement PR #%d: %w", prNumber, err)
+	}
+
+	if verbose {
+		fmt.Printf("Implemented open steps of PR #%d\n", prNumber)
+	}
+	return nil
+}
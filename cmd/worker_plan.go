@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dhamidi/kratt/worker"
+	"github.com/spf13/cobra"
+)
+
+var workerPlanCmd = &cobra.Command{
+	Use:   "plan <pr-number>",
+	Short: "Write an implementation plan for a pull request and wait for approval",
+	Long:  "Runs the agent to write an implementation plan to the branch's implementation status file and posts it to the pull request. Approving the run with kratt approve or a /kratt approve comment executes the plan step by step.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkerPlan,
+}
+
+func init() {
+	workerCmd.AddCommand(workerPlanCmd)
+}
+
+func runWorkerPlan(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	prNumber, err := strconv.Atoi(args[0])
+	if err != nil || prNumber <= 0 {
+		return fmt.Errorf("invalid pull request number: must be a positive integer")
+	}
+
+	w, err := newPRWorker(worker.TaskPlan, defaultImplementInstructions)
+	if err != nil {
+		return err
+	}
+
+	if err := w.PlanPR(ctx, prNumber); err != nil {
+		return fmt.Errorf("failed to plan PR #%d: %w", prNumber, err)
+	}
+
+	if verbose {
+		fmt.Printf("Posted implementation plan to PR #%d\n", prNumber)
+	}
+	return nil
+}
@@ -1,37 +1,58 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/dhamidi/kratt/worker"
 	"github.com/spf13/cobra"
 )
 
 var workerRunCmd = &cobra.Command{
-	Use:   "run <pr-number>",
+	Use:   "run <pr-number> | <owner/repo#pr-number>",
 	Short: "Process a specific pull request",
-	Long:  "Runs the worker to process a specific pull request in the current repository.",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runWorkerRun,
+	Long: `Runs the worker to process a specific pull request in the current repository.
+
+Given owner/repo#pr-number, the pull request is processed without a local
+checkout: the repository is kept as a bare mirror in ~/.kratt/mirrors and the
+PR is processed in a disposable worktree of the mirror.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkerRun,
 }
 
+var (
+	taskType        string
+	compareAgents   []string
+	requireApproval bool
+)
+
 func init() {
+	workerRunCmd.Flags().StringVar(&taskType, "task", worker.TaskReview, "Type of work for agent routing: review, implement or fix-tests")
+	workerRunCmd.Flags().StringSliceVar(&compareAgents, "compare", nil, "Run each of the named agent profiles on the PR and post a comparison")
+	workerRunCmd.Flags().BoolVar(&requireApproval, "require-approval", false, "Commit changes locally and push only after kratt approve or a /kratt approve comment")
 	workerCmd.AddCommand(workerRunCmd)
 }
 
 func runWorkerRun(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	if repo, number, ok := strings.Cut(args[0], "#"); ok {
+		return runWorkerRunRemote(ctx, repo, number)
+	}
+
 	// Parse PR number
-	prNumber, err := strconv.Atoi(args[0])
-	if err != nil || prNumber <= 0 {
-		return fmt.Errorf("invalid pull request number: must be a positive integer")
+	prNumber, err := parsePRNumber(args[0])
+	if err != nil {
+		return err
 	}
 
 	// Create git runner and check if we're in a git repository
 	gitRunner := &worker.GitRunner{}
-	isGitRepo, err := gitRunner.IsGitRepository()
+	isGitRepo, err := gitRunner.IsGitRepository(ctx)
 	if err != nil {
 		return fmt.Errorf("error checking git repository: %w", err)
 	}
@@ -40,7 +61,7 @@ func runWorkerRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get GitHub repository information
-	owner, repo, err := gitRunner.GetGitHubRepository()
+	owner, repo, err := gitRunner.GetGitHubRepository(ctx)
 	if err != nil {
 		return fmt.Errorf("no GitHub remote found in current repository: %w", err)
 	}
@@ -49,38 +70,24 @@ func runWorkerRun(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Processing PR #%d in repository %s/%s\n", prNumber, owner, repo)
 	}
 
-	// Load custom instructions if specified
-	var instructionsText string
-	if instructions != "" {
-		file, err := os.Open(instructions)
-		if err != nil {
-			return fmt.Errorf("failed to open instructions file: %w", err)
-		}
-		defer file.Close()
-
-		content, err := io.ReadAll(file)
-		if err != nil {
-			return fmt.Errorf("failed to read instructions file: %w", err)
-		}
-		instructionsText = string(content)
-	} else {
-		instructionsText = "You are an AI assistant helping with code review. Please analyze the pull request and make any necessary improvements to the code."
+	w, err := newPRWorker(taskType, defaultReviewInstructions)
+	if err != nil {
+		return err
 	}
+	w.RequireApproval = requireApproval
 
-	// Create worker with configuration
-	w := &worker.Worker{
-		Instructions: instructionsText,
-		AgentCommand: agentCommand,
-		LintCommand:  lintCommand,
-		TestCommand:  testCommand,
-		Deadline:     timeout,
-		Git:          gitRunner,
-		GitHub:       &worker.GitHubCLI{},
-		Runner:       &worker.ExecRunner{},
+	if len(compareAgents) > 0 {
+		if err := w.ComparePR(ctx, prNumber, compareAgents); err != nil {
+			return fmt.Errorf("failed to compare agents on PR #%d: %w", prNumber, err)
+		}
+		if verbose {
+			fmt.Printf("Successfully compared agents on PR #%d\n", prNumber)
+		}
+		return nil
 	}
 
 	// Process the pull request
-	if err := w.ProcessPR(prNumber); err != nil {
+	if err := w.ProcessPR(ctx, prNumber); err != nil {
 		return fmt.Errorf("failed to process PR #%d: %w", prNumber, err)
 	}
 
@@ -90,3 +97,131 @@ func runWorkerRun(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runWorkerRunRemote processes a pull request of a repository that is not checked out
+func runWorkerRunRemote(ctx context.Context, repo, number string) error {
+	if _, _, err := worker.SplitRepo(repo); err != nil {
+		return err
+	}
+	prNumber, err := parsePRNumber(number)
+	if err != nil {
+		return err
+	}
+	if len(compareAgents) > 0 {
+		return fmt.Errorf("--compare is not supported for owner/repo#pr-number")
+	}
+
+	if verbose {
+		fmt.Printf("Processing PR #%d in repository %s\n", prNumber, repo)
+	}
+
+	w, err := newPRWorker(taskType, defaultReviewInstructions)
+	if err != nil {
+		return err
+	}
+	w.RequireApproval = requireApproval
+	w.GitHub = newGitHub(repo)
+
+	if err := w.ProcessRemotePR(ctx, repo, prNumber); err != nil {
+		return fmt.Errorf("failed to process PR %s#%d: %w", repo, prNumber, err)
+	}
+
+	if verbose {
+		fmt.Printf("Successfully processed PR %s#%d\n", repo, prNumber)
+	}
+	return nil
+}
+
+// parsePRNumber parses a pull request number argument
+func parsePRNumber(arg string) (int, error) {
+	prNumber, err := strconv.Atoi(arg)
+	if err != nil || prNumber <= 0 {
+		return 0, fmt.Errorf("invalid pull request number: must be a positive integer")
+	}
+	return prNumber, nil
+}
+
+// Default agent instructions used when --instructions is not given
+const (
+	defaultReviewInstructions    = "You are an AI assistant helping with code review. Please analyze the pull request and make any necessary improvements to the code."
+	defaultImplementInstructions = "You are an AI assistant helping with implementation. Please analyze the instructions and implement the requested feature."
+)
+
+// loadInstructions returns the content of the --instructions file, or defaultText if none is given
+func loadInstructions(defaultText string) (string, error) {
+	if instructions == "" {
+		return defaultText, nil
+	}
+
+	file, err := os.Open(instructions)
+	if err != nil {
+		return "", fmt.Errorf("failed to open instructions file: %w", err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read instructions file: %w", err)
+	}
+	return string(content), nil
+}
+
+// newPRWorker creates a worker for processing pull requests from flags and the configuration file
+func newPRWorker(task, defaultInstructions string) (*worker.Worker, error) {
+	instructionsText, err := loadInstructions(defaultInstructions)
+	if err != nil {
+		return nil, err
+	}
+
+	agent, err := worker.NewAgent(agentMode, agentCommand)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent configuration: %w", err)
+	}
+
+	config, err := loadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := newRunStore()
+	if err != nil {
+		return nil, err
+	}
+
+	git, err := newLocalGit(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create worker with configuration
+	w := &worker.Worker{
+		Instructions:   instructionsText,
+		AgentCommand:   agentCommand,
+		LintCommand:    lintCommand,
+		TestCommand:    testCommand,
+		Deadline:       timeout,
+		Timeouts:       phaseTimeouts(),
+		Agent:          agent,
+		ResultProtocol: agentResult,
+		TaskType:       task,
+		Budget:         worker.PlanBudget{MaxSteps: worker.DefaultPlanMaxSteps},
+		Runs:           runs,
+		Git:            git,
+		GitHub:         newGitHub(""),
+		Redactor:       newRedactor(),
+		Runner:         newExecRunner(config),
+	}
+
+	if err := applyConfig(w, config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	w.CoAuthor = coAuthor
+	if w.CoAuthor == "" && config.Commit != nil && config.Commit.CoAuthor {
+		w.CoAuthor, err = worker.GitUserIdentity(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine co-author: %w", err)
+		}
+	}
+	return w, nil
+}
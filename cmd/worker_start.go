@@ -2,36 +2,74 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"regexp"
+	"text/tabwriter"
 
 	"github.com/dhamidi/kratt/worker"
 	"github.com/spf13/cobra"
 )
 
 var workerStartCmd = &cobra.Command{
-	Use:   "start <branch-name> <instructions>",
+	Use:   "start <branch-name> <instructions> | --issue <number> [branch-name] | --from <manifest>",
 	Short: "Create a new branch with instructions and open a pull request",
-	Long:  "Creates a new branch with instructions and opens a pull request for implementation.",
-	Args:  cobra.ExactArgs(2),
+	Long:  "Creates a new branch with instructions and opens a pull request for implementation. With --issue the instructions, branch name and PR title are taken from a GitHub issue, and the PR closes the issue. With --from a branch and PR is created for each task of a YAML manifest or Markdown list.",
+	Args:  validateWorkerStartArgs,
 	RunE:  runWorkerStart,
 }
 
+var (
+	startBase      string
+	startTitle     string
+	startDraft     bool
+	startLabels    []string
+	startReviewers []string
+	startAssignees []string
+	startMilestone string
+	startIssue     int
+	startRun       bool
+	startManifest  string
+)
+
 func init() {
+	workerStartCmd.Flags().StringVar(&startBase, "base", "", "Branch to start from and open the pull request against (default: the remote's default branch)")
+	workerStartCmd.Flags().StringVar(&startTitle, "title", "", "Pull request title (default: the first line of the instructions)")
+	workerStartCmd.Flags().BoolVar(&startDraft, "draft", false, "Open the pull request as a draft")
+	workerStartCmd.Flags().StringSliceVar(&startLabels, "label", nil, "Add labels to the pull request")
+	workerStartCmd.Flags().StringSliceVar(&startReviewers, "reviewer", nil, "Request reviews from users or teams")
+	workerStartCmd.Flags().StringSliceVar(&startAssignees, "assignee", nil, "Assign users to the pull request")
+	workerStartCmd.Flags().StringVar(&startMilestone, "milestone", "", "Add the pull request to a milestone")
+	workerStartCmd.Flags().IntVar(&startIssue, "issue", 0, "Take the instructions from this GitHub issue")
+	workerStartCmd.Flags().BoolVar(&startRun, "run", false, "Run the agent on the new pull request right away")
+	workerStartCmd.Flags().StringVar(&startManifest, "from", "", "Create a branch and pull request for each task in a YAML manifest or Markdown list")
 	workerCmd.AddCommand(workerStartCmd)
 }
 
+// validateWorkerStartArgs requires a branch name and instructions, or an
+// optional branch name with --issue
+func validateWorkerStartArgs(cmd *cobra.Command, args []string) error {
+	if startManifest != "" {
+		if startIssue != 0 || startRun || startTitle != "" {
+			return fmt.Errorf("--from cannot be combined with --issue, --run or --title")
+		}
+		return cobra.NoArgs(cmd, args)
+	}
+	if startIssue != 0 {
+		return cobra.MaximumNArgs(1)(cmd, args)
+	}
+	return cobra.ExactArgs(2)(cmd, args)
+}
+
 func runWorkerStart(cmd *cobra.Command, args []string) error {
-	branchName := args[0]
-	instructions := args[1]
+	ctx := cmd.Context()
 
-	// Validate branch name
-	if !isValidBranchName(branchName) {
-		return fmt.Errorf("invalid branch name: must not contain invalid characters")
+	if startIssue < 0 {
+		return fmt.Errorf("invalid issue number: must be a positive integer")
 	}
 
 	// Create git runner and check if we're in a git repository
 	gitRunner := &worker.GitRunner{}
-	isGitRepo, err := gitRunner.IsGitRepository()
+	isGitRepo, err := gitRunner.IsGitRepository(ctx)
 	if err != nil {
 		return fmt.Errorf("error checking git repository: %w", err)
 	}
@@ -40,17 +78,46 @@ func runWorkerStart(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get GitHub repository information
-	owner, repo, err := gitRunner.GetGitHubRepository()
+	owner, repo, err := gitRunner.GetGitHubRepository(ctx)
 	if err != nil {
 		return fmt.Errorf("no GitHub remote found in current repository: %w", err)
 	}
 
+	w, err := newPRWorker(worker.TaskImplement, defaultImplementInstructions)
+	if err != nil {
+		return err
+	}
+
+	if startManifest != "" {
+		return runWorkerStartBatch(cmd, w)
+	}
+
+	var issue *worker.Issue
+	var branchName string
+	if startIssue != 0 {
+		issue, err = w.FetchIssue(ctx, startIssue)
+		if err != nil {
+			return err
+		}
+		branchName = worker.IssueBranchName(issue)
+		if len(args) > 0 {
+			branchName = args[0]
+		}
+	} else {
+		branchName = args[0]
+	}
+
+	// Validate branch name
+	if !isValidBranchName(branchName) {
+		return fmt.Errorf("invalid branch name: must not contain invalid characters")
+	}
+
 	if verbose {
 		fmt.Printf("Creating branch %s in repository %s/%s\n", branchName, owner, repo)
 	}
 
 	// Check if branch already exists
-	exists, err := gitRunner.BranchExists(branchName)
+	exists, err := gitRunner.BranchExists(ctx, branchName)
 	if err != nil {
 		return fmt.Errorf("error checking if branch exists: %w", err)
 	}
@@ -58,27 +125,35 @@ func runWorkerStart(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("branch already exists: %s", branchName)
 	}
 
-	// Create worker with configuration
-	w := &worker.Worker{
-		Instructions: "You are an AI assistant helping with implementation. Please analyze the instructions and implement the requested feature.",
-		AgentCommand: agentCommand,
-		LintCommand:  lintCommand,
-		TestCommand:  testCommand,
-		Deadline:     timeout,
-		Git:          gitRunner,
-		GitHub:       &worker.GitHubCLI{},
-		Runner:       &worker.ExecRunner{},
-	}
-
 	// Start the new branch and create PR
-	if err := w.Start(branchName, instructions); err != nil {
+	task := worker.StartTask{Branch: branchName, Title: startTitle, PROptions: startPROptions()}
+	var pr worker.PullRequest
+	if issue != nil {
+		pr, err = w.StartIssue(ctx, task, issue)
+	} else {
+		task.Instructions = args[1]
+		pr, err = w.StartWith(ctx, task)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to start branch %s: %w", branchName, err)
 	}
 
 	if verbose {
-		fmt.Printf("Successfully created branch %s and opened pull request\n", branchName)
+		fmt.Printf("Successfully created branch %s and opened pull request #%d\n", branchName, pr.Number)
 	}
+	fmt.Fprintln(cmd.OutOrStdout(), pr.URL)
 
+	if !startRun {
+		return nil
+	}
+
+	if err := w.ProcessPR(ctx, pr.Number); err != nil {
+		return fmt.Errorf("failed to process PR #%d: %w", pr.Number, err)
+	}
+
+	if verbose {
+		fmt.Printf("Successfully processed PR #%d\n", pr.Number)
+	}
 	return nil
 }
 
@@ -104,3 +179,81 @@ func isValidBranchName(name string) bool {
 
 	return true
 }
+
+// startPROptions returns the pull request options given as flags
+func startPROptions() worker.PROptions {
+	return worker.PROptions{
+		Base:      startBase,
+		Draft:     startDraft,
+		Labels:    startLabels,
+		Reviewers: startReviewers,
+		Assignees: startAssignees,
+		Milestone: startMilestone,
+	}
+}
+
+// runWorkerStartBatch starts every task of the manifest and prints a summary table
+func runWorkerStartBatch(cmd *cobra.Command, w *worker.Worker) error {
+	tasks, err := loadManifest(startManifest)
+	if err != nil {
+		return err
+	}
+	// Flags provide defaults for the options a task leaves unset
+	defaults := startPROptions()
+	for i := range tasks {
+		options := &tasks[i].PROptions
+		if options.Base == "" {
+			options.Base = defaults.Base
+		}
+		options.Draft = options.Draft || defaults.Draft
+		if options.Labels == nil {
+			options.Labels = defaults.Labels
+		}
+		if options.Reviewers == nil {
+			options.Reviewers = defaults.Reviewers
+		}
+		if options.Assignees == nil {
+			options.Assignees = defaults.Assignees
+		}
+		if options.Milestone == "" {
+			options.Milestone = defaults.Milestone
+		}
+	}
+
+	results, err := w.StartBatch(cmd.Context(), tasks)
+	printStartResults(cmd.OutOrStdout(), results)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == worker.StartFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tasks failed", failed, len(results))
+	}
+	return nil
+}
+
+// printStartResults prints one row per task of a batch
+func printStartResults(out io.Writer, results []worker.StartResult) {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "BRANCH\tSTATUS\tPR\tDETAILS")
+	for _, result := range results {
+		pr := "-"
+		if result.PRNumber != 0 {
+			pr = fmt.Sprintf("#%d", result.PRNumber)
+		}
+		details := ""
+		if result.Err != nil {
+			details = result.Err.Error()
+		} else if result.Status == worker.StartSkipped {
+			details = "branch or pull request already exists"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", result.Task.Branch, result.Status, pr, details)
+	}
+	table.Flush()
+}
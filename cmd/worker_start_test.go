@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"testing"
 
 	"github.com/dhamidi/kratt/worker"
@@ -61,7 +62,7 @@ func TestRunWorkerStart_NotGitRepository(t *testing.T) {
 	}
 
 	// Test the git repository check logic
-	isRepo, err := git.IsGitRepository()
+	isRepo, err := git.IsGitRepository(context.Background())
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -73,14 +74,14 @@ func TestRunWorkerStart_NotGitRepository(t *testing.T) {
 func TestRunWorkerStart_BranchAlreadyExists(t *testing.T) {
 	git := worker.NewFakeLocalGit()
 	git.SetGitHubRepository("testowner", "testrepo")
-	git.CreateBranch("existing-branch") // This adds it to the fake state
+	git.CreateBranch(context.Background(), "existing-branch", "origin/main", "/fake/repo-existing-branch") // This adds it to the fake state
 
-	isRepo, _ := git.IsGitRepository()
+	isRepo, _ := git.IsGitRepository(context.Background())
 	if !isRepo {
 		t.Error("Expected to be a git repository")
 	}
 
-	exists, _ := git.BranchExists("existing-branch")
+	exists, _ := git.BranchExists(context.Background(), "existing-branch")
 	if !exists {
 		t.Error("Expected branch to exist")
 	}
@@ -90,12 +91,12 @@ func TestRunWorkerStart_GitHubRepositoryError(t *testing.T) {
 	git := worker.NewFakeLocalGit()
 	git.FailGetGitHubRepository = true
 
-	isRepo, _ := git.IsGitRepository()
+	isRepo, _ := git.IsGitRepository(context.Background())
 	if !isRepo {
 		t.Error("Expected to be a git repository")
 	}
 
-	_, _, err := git.GetGitHubRepository()
+	_, _, err := git.GetGitHubRepository(context.Background())
 	if err == nil {
 		t.Error("Expected error when getting GitHub repository")
 	}
@@ -113,7 +114,7 @@ func TestRunWorkerStart_Success(t *testing.T) {
 		Runner: worker.NewFakeCommandRunner(),
 	}
 
-	err := w.Start("test-branch", "Test instructions")
+	err := w.Start(context.Background(), "test-branch", "Test instructions")
 	if err != nil {
 		t.Errorf("Expected successful start, got error: %v", err)
 	}
@@ -132,7 +133,7 @@ func TestRunWorkerStart_CreatePRError(t *testing.T) {
 		Runner: worker.NewFakeCommandRunner(),
 	}
 
-	err := w.Start("test-branch", "Test instructions")
+	err := w.Start(context.Background(), "test-branch", "Test instructions")
 	if err == nil {
 		t.Error("Expected error when creating PR")
 	}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/dhamidi/kratt/worker"
+	"github.com/spf13/cobra"
+)
+
+var worktreeCmd = &cobra.Command{
+	Use:   "worktree",
+	Short: "Manage the worktrees kratt creates for pull requests",
+	Long:  "Lists, prunes and resets the worktrees kratt creates for pull request branches.",
+}
+
+var worktreeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List worktrees and the runs that created them",
+	Args:  cobra.NoArgs,
+	RunE:  runWorktreeList,
+}
+
+var worktreePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove worktrees whose pull request was merged or closed",
+	Long:  "Removes the worktrees kratt runs created for branches whose most recent pull request was merged or closed. Worktrees not created by a recorded run are left alone, and worktrees with uncommitted changes or an unfinished operation are kept unless --force is given.",
+	Args:  cobra.NoArgs,
+	RunE:  runWorktreePrune,
+}
+
+var worktreeResetCmd = &cobra.Command{
+	Use:   "reset <branch>",
+	Short: "Reset a branch's worktree to the remote branch",
+	Long:  "Fetches the branch from origin and hard-resets its worktree to it, discarding local commits and changes to tracked files.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorktreeReset,
+}
+
+var (
+	pruneDryRun bool
+	pruneForce  bool
+)
+
+func init() {
+	worktreePruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Only list the worktrees that would be removed")
+	worktreePruneCmd.Flags().BoolVar(&pruneForce, "force", false, "Also remove worktrees with uncommitted changes, which are lost")
+	worktreeCmd.AddCommand(worktreeListCmd)
+	worktreeCmd.AddCommand(worktreePruneCmd)
+	worktreeCmd.AddCommand(worktreeResetCmd)
+	rootCmd.AddCommand(worktreeCmd)
+}
+
+func runWorktreeList(cmd *cobra.Command, args []string) error {
+	w, err := newPRWorker(worker.TaskReview, defaultReviewInstructions)
+	if err != nil {
+		return err
+	}
+	infos, err := w.ListWorktrees(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PATH\tBRANCH\tHEAD\tRUN\tPR\tCREATED")
+	for _, info := range infos {
+		run, pr, created := "-", "-", "-"
+		if info.RunID != "" {
+			run = info.RunID
+			pr = fmt.Sprintf("#%d", info.PRNumber)
+			created = info.CreatedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", info.Path, valueOrDash(info.Branch), shortHead(info.Head), run, pr, created)
+	}
+	return table.Flush()
+}
+
+func runWorktreePrune(cmd *cobra.Command, args []string) error {
+	w, err := newPRWorker(worker.TaskReview, defaultReviewInstructions)
+	if err != nil {
+		return err
+	}
+	pruned, err := w.PruneWorktrees(cmd.Context(), pruneDryRun, pruneForce)
+	for _, info := range pruned {
+		if info.Kept != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Kept %s (%s, PR %s): %s\n", info.Path, info.Branch, info.PRState, info.Kept)
+			continue
+		}
+		action := "Removed"
+		if pruneDryRun {
+			action = "Would remove"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s (%s, PR %s)\n", action, info.Path, info.Branch, info.PRState)
+	}
+	return err
+}
+
+func runWorktreeReset(cmd *cobra.Command, args []string) error {
+	w, err := newPRWorker(worker.TaskReview, defaultReviewInstructions)
+	if err != nil {
+		return err
+	}
+	if err := w.ResetWorktree(cmd.Context(), args[0]); err != nil {
+		return fmt.Errorf("failed to reset worktree of %s: %w", args[0], err)
+	}
+	if verbose {
+		fmt.Printf("Reset worktree of %s to origin/%s\n", args[0], args[0])
+	}
+	return nil
+}
+
+// valueOrDash returns "-" for empty table cells
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// shortHead abbreviates a commit SHA for display
+func shortHead(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return valueOrDash(sha)
+}
@@ -0,0 +1,279 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Agent interface encapsulates running an AI coding agent on a prompt
+type Agent interface {
+	// Name returns a short human readable name for the agent
+	Name() string
+
+	// Run executes the agent with the given prompt in the current directory.
+	// Adapters that can extract a structured result from the agent's output
+	// return it, others return nil.
+	Run(ctx context.Context, runner CommandRunner, prompt string) (*AgentResult, error)
+}
+
+// AgentResult is the structured result an agent reports about its work
+type AgentResult struct {
+	Summary           string   `json:"summary"`
+	FilesTouched      []string `json:"files_touched,omitempty"`
+	Confidence        string   `json:"confidence,omitempty"`
+	FollowUpQuestions []string `json:"follow_up_questions,omitempty"`
+}
+
+// Agent modes accepted by NewAgent
+const (
+	AgentModeStdin      = "stdin"
+	AgentModePromptFile = "prompt-file"
+	AgentModeJSONLines  = "jsonl"
+)
+
+// PromptFilePlaceholder is replaced with the prompt file path in PromptFileAgent commands
+const PromptFilePlaceholder = "{prompt}"
+
+// NewAgent creates an agent adapter for the given mode and command
+func NewAgent(mode string, command []string) (Agent, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("agent command must not be empty")
+	}
+
+	switch mode {
+	case "", AgentModeStdin:
+		return &StdinAgent{Command: command}, nil
+	case AgentModePromptFile:
+		return &PromptFileAgent{Command: command}, nil
+	case AgentModeJSONLines:
+		return &JSONLinesAgent{Command: command}, nil
+	default:
+		return nil, fmt.Errorf("unknown agent mode %q: must be one of %s, %s, %s", mode, AgentModeStdin, AgentModePromptFile, AgentModeJSONLines)
+	}
+}
+
+// StdinAgent runs an agent CLI that reads its prompt from stdin
+type StdinAgent struct {
+	Command []string
+}
+
+// Name returns the agent executable name
+func (a *StdinAgent) Name() string {
+	return a.Command[0]
+}
+
+// Run feeds the prompt to the agent via stdin
+func (a *StdinAgent) Run(ctx context.Context, runner CommandRunner, prompt string) (*AgentResult, error) {
+	if err := runner.RunWithStdin(ctx, prompt, a.Command[0], a.Command[1:]...); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// PromptFileAgent runs an agent CLI that reads its prompt from a file given as argument.
+// Every PromptFilePlaceholder in Command is replaced with the path of the prompt file;
+// without a placeholder the path is appended as the last argument.
+type PromptFileAgent struct {
+	Command []string
+}
+
+// Name returns the agent executable name
+func (a *PromptFileAgent) Name() string {
+	return a.Command[0]
+}
+
+// Run writes the prompt to a temporary file and passes its path to the agent
+func (a *PromptFileAgent) Run(ctx context.Context, runner CommandRunner, prompt string) (*AgentResult, error) {
+	scratch, err := scratchDir()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(scratch, "prompt-*.md")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prompt file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(prompt); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write prompt file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close prompt file: %w", err)
+	}
+
+	args := promptFileArgs(a.Command[1:], file.Name())
+	if _, err := runner.RunWithOutput(ctx, a.Command[0], args...); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// promptFileArgs substitutes the prompt file path into the agent arguments
+func promptFileArgs(args []string, path string) []string {
+	result := make([]string, 0, len(args)+1)
+	substituted := false
+	for _, arg := range args {
+		if strings.Contains(arg, PromptFilePlaceholder) {
+			arg = strings.ReplaceAll(arg, PromptFilePlaceholder, path)
+			substituted = true
+		}
+		result = append(result, arg)
+	}
+	if !substituted {
+		result = append(result, path)
+	}
+	return result
+}
+
+// JSONLinesAgent runs an agent CLI that reads its prompt from stdin and
+// streams JSON events, one per line, on stdout. The last event of type
+// "result" is turned into an AgentResult.
+type JSONLinesAgent struct {
+	Command []string
+}
+
+// Name returns the agent executable name
+func (a *JSONLinesAgent) Name() string {
+	return a.Command[0]
+}
+
+// Run feeds the prompt via stdin and parses the agent's JSON event stream
+func (a *JSONLinesAgent) Run(ctx context.Context, runner CommandRunner, prompt string) (*AgentResult, error) {
+	output, err := runner.RunWithStdinOutput(ctx, prompt, a.Command[0], a.Command[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONLinesResult(output), nil
+}
+
+// jsonLinesEvent is the subset of a streamed agent event kratt understands
+type jsonLinesEvent struct {
+	Type   string `json:"type"`
+	Result string `json:"result"`
+	AgentResult
+}
+
+// parseJSONLinesResult extracts the final result event from a JSON-lines stream
+func parseJSONLinesResult(output []byte) *AgentResult {
+	var result *AgentResult
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var event jsonLinesEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type != "result" {
+			continue
+		}
+
+		r := event.AgentResult
+		if r.Summary == "" {
+			r.Summary = event.Result
+		}
+		result = &r
+	}
+	return result
+}
+
+// resultProtocolInstructions returns the prompt section asking the agent to write a result file
+func resultProtocolInstructions(path string) string {
+	var b strings.Builder
+	b.WriteString("<result-protocol>\n")
+	b.WriteString("When you are done, write a JSON object describing your work to ")
+	b.WriteString(path)
+	b.WriteString(" with these fields:\n")
+	b.WriteString(`- "summary": a short description of the changes you made` + "\n")
+	b.WriteString(`- "files_touched": the list of files you changed` + "\n")
+	b.WriteString(`- "confidence": "high", "medium" or "low"` + "\n")
+	b.WriteString(`- "follow_up_questions": questions for the reviewers, if any` + "\n")
+	b.WriteString("Do not add this file to the repository.\n")
+	b.WriteString("</result-protocol>")
+	return b.String()
+}
+
+// readAgentResult reads a result file written by the agent.
+// A missing file is not an error and yields a nil result.
+func readAgentResult(path string) (*AgentResult, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent result file %s: %w", path, err)
+	}
+
+	var result AgentResult
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse agent result file %s: %w", path, err)
+	}
+	return &result, nil
+}
+
+// FakeAgent implements Agent interface for testing
+type FakeAgent struct {
+	AgentName string
+	Result    *AgentResult
+	Err       error
+
+	// ResultFileContent is written to the result file named in the prompt, if any
+	ResultFileContent string
+
+	// Block makes Run wait until its context is done and return the context error
+	Block bool
+
+	prompts []string
+}
+
+// NewFakeAgent creates a new FakeAgent instance
+func NewFakeAgent(name string) *FakeAgent {
+	return &FakeAgent{AgentName: name, prompts: []string{}}
+}
+
+// Name returns the configured agent name
+func (f *FakeAgent) Name() string {
+	return f.AgentName
+}
+
+// Run records the prompt and returns the configured result
+func (f *FakeAgent) Run(ctx context.Context, runner CommandRunner, prompt string) (*AgentResult, error) {
+	f.prompts = append(f.prompts, prompt)
+	if f.Block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.ResultFileContent != "" {
+		if path := resultFileFromPrompt(prompt); path != "" {
+			if err := os.WriteFile(path, []byte(f.ResultFileContent), 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f.Result, f.Err
+}
+
+// GetPrompts returns all prompts the agent was run with (for testing)
+func (f *FakeAgent) GetPrompts() []string {
+	return f.prompts
+}
+
+// resultFileFromPrompt finds the result file path requested in a prompt
+func resultFileFromPrompt(prompt string) string {
+	const marker = "write a JSON object describing your work to "
+	i := strings.Index(prompt, marker)
+	if i < 0 {
+		return ""
+	}
+	rest := prompt[i+len(marker):]
+	if j := strings.Index(rest, " with these fields"); j >= 0 {
+		return rest[:j]
+	}
+	return ""
+}
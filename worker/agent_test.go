@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAgent(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{"", "*worker.StdinAgent"},
+		{AgentModeStdin, "*worker.StdinAgent"},
+		{AgentModePromptFile, "*worker.PromptFileAgent"},
+		{AgentModeJSONLines, "*worker.JSONLinesAgent"},
+	}
+
+	for _, tt := range tests {
+		agent, err := NewAgent(tt.mode, []string{"amp", "--stdin"})
+		if err != nil {
+			t.Fatalf("NewAgent(%q) failed: %v", tt.mode, err)
+		}
+		if got := fmt.Sprintf("%T", agent); got != tt.expected {
+			t.Errorf("NewAgent(%q) = %s, want %s", tt.mode, got, tt.expected)
+		}
+	}
+
+	if _, err := NewAgent("carrier-pigeon", []string{"amp"}); err == nil {
+		t.Error("Expected error for unknown agent mode")
+	}
+	if _, err := NewAgent(AgentModeStdin, nil); err == nil {
+		t.Error("Expected error for empty agent command")
+	}
+}
+
+func TestPromptFileArgs(t *testing.T) {
+	got := promptFileArgs([]string{"run", "--prompt={prompt}"}, "/tmp/p.md")
+	if strings.Join(got, " ") != "run --prompt=/tmp/p.md" {
+		t.Errorf("Expected placeholder to be substituted, got %v", got)
+	}
+
+	got = promptFileArgs([]string{"run"}, "/tmp/p.md")
+	if strings.Join(got, " ") != "run /tmp/p.md" {
+		t.Errorf("Expected path to be appended, got %v", got)
+	}
+}
+
+func TestJSONLinesAgent(t *testing.T) {
+	runner := NewFakeCommandRunner()
+	stream := `{"type":"system","subtype":"init"}
+not json
+{"type":"assistant","message":"working"}
+{"type":"result","result":"Fixed the flaky test","confidence":"high"}
+`
+	runner.SetResponse("claude -p --output-format stream-json", []byte(stream), nil)
+
+	agent := &JSONLinesAgent{Command: []string{"claude", "-p", "--output-format", "stream-json"}}
+	result, err := agent.Run(context.Background(), runner, "do things")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected a result to be parsed from the stream")
+	}
+	if result.Summary != "Fixed the flaky test" || result.Confidence != "high" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if runner.GetStdinInput("claude -p --output-format stream-json") != "do things" {
+		t.Error("Expected prompt to be passed via stdin")
+	}
+}
+
+func TestWorkerProcessPRWithResultProtocol(t *testing.T) {
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(7, `{"headRefName": "feature-branch"}`)
+
+	agent := NewFakeAgent("fake")
+	agent.ResultFileContent = `{
+		"summary": "Added input validation",
+		"files_touched": ["handler.go"],
+		"confidence": "medium",
+		"follow_up_questions": ["Should empty names be allowed?"]
+	}`
+
+	w := &Worker{
+		Instructions:   "You are a helpful AI assistant.",
+		LintCommand:    []string{"go", "vet", "./..."},
+		TestCommand:    []string{"go", "test", "./..."},
+		Deadline:       5 * time.Second,
+		Agent:          agent,
+		ResultProtocol: true,
+		Git:            NewFakeLocalGit(),
+		GitHub:         fakeGitHub,
+		Runner:         NewFakeCommandRunner(),
+	}
+
+	if err := w.ProcessPR(context.Background(), 7); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+
+	prompts := agent.GetPrompts()
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "<result-protocol>") {
+		t.Error("Expected prompt to contain result protocol instructions")
+	}
+
+	comments := fakeGitHub.GetComments(7)
+	if len(comments) != 1 {
+		t.Fatalf("Expected 1 comment, got %d", len(comments))
+	}
+	for _, want := range []string{"### Agent Summary", "Added input validation", "`handler.go`", "**Confidence:** medium", "Should empty names be allowed?"} {
+		if !strings.Contains(comments[0], want) {
+			t.Errorf("Expected comment to contain %q, got:\n%s", want, comments[0])
+		}
+	}
+}
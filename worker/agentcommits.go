@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Policies for commits the agent created itself
+const (
+	AgentCommitsPreserve = "preserve"
+	AgentCommitsSquash   = "squash"
+)
+
+// checkAgentWork returns the commits the agent created since base and checks
+// them, together with its uncommitted changes, with the policy and the secret
+// scanner
+func (w *Worker) checkAgentWork(ctx context.Context, base string) ([]CommitInfo, []PolicyViolation, []SecretFinding, error) {
+	commits, err := w.Git.CommitsSince(ctx, base)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	violations, findings, err := w.checkChanges(ctx, base)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to check changes: %w", err)
+	}
+	if w.Policy != nil && w.AgentCommits != AgentCommitsSquash {
+		violations = append(violations, w.Policy.EvaluateCommits(commits)...)
+	}
+	return commits, violations, findings, nil
+}
+
+// squashAgentCommits folds the agent's own commits into the staged changes
+// when AgentCommits is AgentCommitsSquash, so that they are committed together
+func (w *Worker) squashAgentCommits(ctx context.Context, base string, commits []CommitInfo) ([]CommitInfo, error) {
+	if w.AgentCommits != AgentCommitsSquash || len(commits) == 0 {
+		return commits, nil
+	}
+	if err := w.Git.ResetSoft(ctx, base); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// pushAgentWork commits the agent's remaining changes with message and
+// pushes them together with the agent's own commits. With AgentCommitsSquash
+// the agent's commits are replaced by a single commit.
+func (w *Worker) pushAgentWork(ctx context.Context, base string, commits []CommitInfo, message string) error {
+	commits, err := w.squashAgentCommits(ctx, base, commits)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return w.Git.CommitAndPush(ctx, message)
+	}
+	if _, err := w.Git.Commit(ctx, message); err != nil {
+		return err
+	}
+	return w.Git.Push(ctx)
+}
+
+// formatAgentCommits lists the agent's own commits by SHA; they are linked
+// only once pushed (see formatPushedAgentCommits)
+func (w *Worker) formatAgentCommits(commits []CommitInfo) string {
+	var b strings.Builder
+	b.WriteString("### 📝 Agent Commits\n\n")
+	if w.AgentCommits == AgentCommitsSquash {
+		fmt.Fprintf(&b, "The agent created %d commit(s); they are squashed into a single commit:\n\n", len(commits))
+	}
+	for _, commit := range commits {
+		fmt.Fprintf(&b, "- `%s` %s\n", shortSHA(commit.SHA), commit.Subject)
+	}
+	return b.String()
+}
+
+// formatPushedAgentCommits lists the agent's pushed commits linked to GitHub
+func (w *Worker) formatPushedAgentCommits(ctx context.Context, commits []CommitInfo) (string, error) {
+	owner, repo, err := w.Git.GetGitHubRepository(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to link agent commits: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString("### 📝 Agent Commits\n\nPushed the agent's commits:\n\n")
+	for _, commit := range commits {
+		fmt.Fprintf(&b, "- [`%s`](https://github.com/%s/%s/commit/%s) %s\n", shortSHA(commit.SHA), owner, repo, commit.SHA, commit.Subject)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,181 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testAgentCommits = []CommitInfo{
+	{SHA: "1111111111111111111111111111111111111111", Subject: "fix: handle empty input"},
+	{SHA: "2222222222222222222222222222222222222222", Subject: "wip"},
+}
+
+func TestWorkerPreservesAgentCommits(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetAgentCommits(testAgentCommits)
+	fakeGitHub := NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+
+	if err := w.ProcessPR(context.Background(), 7); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+	if len(fakeGit.GetSoftResets()) != 0 {
+		t.Errorf("Expected agent commits to be kept, got resets %v", fakeGit.GetSoftResets())
+	}
+	if fakeGit.GetPushCount() != 1 {
+		t.Errorf("Expected agent commits to be pushed, got %d pushes", fakeGit.GetPushCount())
+	}
+
+	comments := fakeGitHub.GetComments(7)
+	if len(comments) != 1 || !strings.Contains(comments[0], "[`1111111`](https://github.com/owner/repo/commit/1111111111111111111111111111111111111111) fix: handle empty input") {
+		t.Fatalf("Expected linked agent commits in the results comment, got %v", comments)
+	}
+	runs, _ := w.Runs.ListRuns()
+	if len(runs) != 1 || len(runs[0].AgentCommits) != 2 || runs[0].BaseSHA == "" || runs[0].CommitSHA == "" {
+		t.Errorf("Expected base, last and agent commits to be recorded, got %+v", runs)
+	}
+}
+
+func TestWorkerReportsUnlinkedAgentCommits(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetAgentCommits(testAgentCommits)
+	fakeGit.FailGetGitHubRepository = true
+	fakeGitHub := NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+
+	if err := w.ProcessPR(context.Background(), 7); err == nil || !strings.Contains(err.Error(), "failed to link agent commits") {
+		t.Fatalf("Expected the repository lookup error to be returned, got %v", err)
+	}
+	if fakeGit.GetPushCount() != 1 {
+		t.Errorf("Expected agent commits to be pushed, got %d pushes", fakeGit.GetPushCount())
+	}
+	if comments := fakeGitHub.GetComments(7); len(comments) != 1 || !strings.Contains(comments[0], "- `1111111` fix: handle empty input") {
+		t.Errorf("Expected unlinked agent commits in the results comment, got %v", comments)
+	}
+}
+
+func TestWorkerSquashesAgentCommits(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetHeadSHA("abcdef0123456789")
+	fakeGit.SetAgentCommits(testAgentCommits)
+	fakeGitHub := NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+	w.AgentCommits = AgentCommitsSquash
+	w.Policy = &Policy{CommitMessagePattern: regexp.MustCompile(`^(feat|fix): `)}
+
+	if err := w.ProcessPR(context.Background(), 7); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+	if resets := fakeGit.GetSoftResets(); len(resets) != 1 || resets[0] != "abcdef0123456789" {
+		t.Errorf("Expected soft reset to the starting commit, got %v", resets)
+	}
+	if commits := fakeGit.GetCommits(); len(commits) != 1 {
+		t.Errorf("Expected a single squashed commit, got %v", commits)
+	}
+	if comments := fakeGitHub.GetComments(7); len(comments) != 1 || !strings.Contains(comments[0], "squashed into a single commit") {
+		t.Errorf("Expected squash to be noted, got %v", comments)
+	}
+}
+
+func TestWorkerRejectsAgentCommitMessages(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetAgentCommits(testAgentCommits)
+	fakeGitHub := NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+	w.Policy = &Policy{CommitMessagePattern: regexp.MustCompile(`^(feat|fix): `)}
+
+	err := w.ProcessPR(context.Background(), 7)
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) != 1 || policyErr.Violations[0].Path != "2222222" {
+		t.Fatalf("Expected commit message violation for 2222222, got %v", err)
+	}
+	if fakeGit.GetPushCount() != 0 || len(fakeGit.GetCommits()) != 0 {
+		t.Error("Expected nothing to be committed or pushed")
+	}
+	if comments := fakeGitHub.GetComments(7); len(comments) != 1 || strings.Contains(comments[0], "](https://") {
+		t.Errorf("Expected blocked commits not to be linked, got %v", comments)
+	}
+}
+
+func TestGoGitCommitsSince(t *testing.T) {
+	ctx := context.Background()
+	g := newMemoryTestGit(t)
+	base, err := g.HeadSHA(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range []string{"feat: add notes\n\nWith a body.", "docs: expand notes"} {
+		g.WriteFile(ctx, "notes.txt", message)
+		if committed, err := g.Commit(ctx, message); err != nil || !committed {
+			t.Fatalf("Commit = %v, %v", committed, err)
+		}
+	}
+
+	commits, err := g.CommitsSince(ctx, base)
+	if err != nil {
+		t.Fatalf("CommitsSince failed: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "feat: add notes" || commits[0].Message != "feat: add notes\n\nWith a body." || commits[1].Subject != "docs: expand notes" {
+		t.Errorf("Unexpected commits %+v", commits)
+	}
+
+	w := &Worker{Git: g, AgentCommits: AgentCommitsSquash}
+	if err := w.pushAgentWork(ctx, base, commits, "docs: add notes"); err == nil || !strings.Contains(err.Error(), "pushing is not supported") {
+		t.Fatalf("Expected the in-memory push to fail after squashing, got %v", err)
+	}
+	if commits, _ := g.CommitsSince(ctx, base); len(commits) != 1 || commits[0].Subject != "docs: add notes" {
+		t.Errorf("Expected the agent's commits to be squashed into one, got %+v", commits)
+	}
+}
+
+func TestGitRunnerCommitsSince(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	repo := t.TempDir()
+	if err := initEvalRepo(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	original, _ := os.Getwd()
+	defer os.Chdir(original)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &GitRunner{}
+	base, err := runner.HeadSHA(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range []string{"feat: add notes\n\nWith a body.", "docs: expand notes"} {
+		os.WriteFile(filepath.Join(repo, "notes.txt"), []byte(message), 0644)
+		if err := runGit(ctx, repo, "add", "-A"); err != nil {
+			t.Fatal(err)
+		}
+		if err := runGit(ctx, repo, "-c", "user.name=test", "-c", "user.email=test@localhost", "commit", "--quiet", "-m", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commits, err := runner.CommitsSince(ctx, base)
+	if err != nil {
+		t.Fatalf("CommitsSince failed: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "feat: add notes" || commits[0].Message != "feat: add notes\n\nWith a body." || commits[1].Subject != "docs: expand notes" {
+		t.Errorf("Unexpected commits %+v", commits)
+	}
+
+	if err := runner.ResetSoft(ctx, base); err != nil {
+		t.Fatalf("ResetSoft failed: %v", err)
+	}
+	if commits, _ := runner.CommitsSince(ctx, base); len(commits) != 0 {
+		t.Errorf("Expected no commits after soft reset, got %+v", commits)
+	}
+	if diff, _ := runner.StagedDiff(ctx, base); !strings.Contains(diff, "notes.txt") {
+		t.Errorf("Expected changes to stay staged, got %q", diff)
+	}
+}
@@ -0,0 +1,217 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Approval commands recognized in PR comments
+const (
+	ApproveCommand = "/kratt approve"
+	RejectCommand  = "/kratt reject"
+)
+
+// trustedAssociations are the GitHub author associations allowed to approve or reject runs
+var trustedAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// requestApproval commits the changes locally and asks for approval instead
+// of pushing. The agent's own commits since run.BaseSHA are part of the
+// approved changes. Without changes there is nothing to approve and the run
+// succeeds.
+func (w *Worker) requestApproval(ctx context.Context, run *RunRecord, commits []CommitInfo, message string) error {
+	if w.Runs == nil {
+		return fmt.Errorf("approval requires run history to resume the run")
+	}
+
+	base := run.BaseSHA
+	if base == "" {
+		var err error
+		if base, err = w.Git.HeadSHA(ctx); err != nil {
+			return err
+		}
+	}
+	commits, err := w.squashAgentCommits(ctx, base, commits)
+	if err != nil {
+		return err
+	}
+	committed, err := w.Git.Commit(ctx, message)
+	if err != nil || (!committed && len(commits) == 0) {
+		return err
+	}
+	commit, err := w.Git.HeadSHA(ctx)
+	if err != nil {
+		return err
+	}
+	stat, err := w.Git.DiffStat(ctx, base)
+	if err != nil {
+		return err
+	}
+
+	run.BaseSHA = base
+	run.CommitSHA = commit
+	run.ApprovalRequestedAt = time.Now()
+	run.Status = RunStatusAwaitingApproval
+	if err := w.saveRun(run); err != nil {
+		return err
+	}
+
+	return w.GitHub.PostComment(ctx, run.PRNumber, formatApprovalRequestComment(run, stat))
+}
+
+// ApproveRun pushes the local commit of a run awaiting approval. An empty
+// reviewer means the run was approved locally.
+func (w *Worker) ApproveRun(ctx context.Context, runID, reviewer string) error {
+	run, err := w.awaitingRun(runID)
+	if err != nil {
+		return err
+	}
+	return w.decideRun(ctx, run, true, reviewer)
+}
+
+// RejectRun discards the local commit of a run awaiting approval
+func (w *Worker) RejectRun(ctx context.Context, runID, reviewer string) error {
+	run, err := w.awaitingRun(runID)
+	if err != nil {
+		return err
+	}
+	return w.decideRun(ctx, run, false, reviewer)
+}
+
+// ResumeRun looks for an approve or reject command from a trusted user on the
+// run's PR, posted after approval was requested, and applies it. It reports
+// whether a decision was found.
+func (w *Worker) ResumeRun(ctx context.Context, runID string) (bool, error) {
+	run, err := w.awaitingRun(runID)
+	if err != nil {
+		return false, err
+	}
+
+	prInfo, err := w.GitHub.GetPRInfo(ctx, run.PRNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to get PR info: %w", err)
+	}
+
+	for _, comment := range parsePRDetails(prInfo).Comments {
+		if comment.CreatedAt.Before(run.ApprovalRequestedAt) || !isTrustedAssociation(comment.AuthorAssociation) {
+			continue
+		}
+		if approve, ok := parseApprovalCommand(comment.Body, run.ID); ok {
+			return true, w.decideRun(ctx, run, approve, comment.Author.Login)
+		}
+	}
+	return false, nil
+}
+
+// awaitingRun loads a run and checks that it is awaiting approval
+func (w *Worker) awaitingRun(runID string) (*RunRecord, error) {
+	if w.Runs == nil {
+		return nil, fmt.Errorf("approval requires run history")
+	}
+	run, err := w.Runs.GetRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != RunStatusAwaitingApproval {
+		return nil, fmt.Errorf("run %s is %s, not awaiting approval", run.ID, run.Status)
+	}
+	return run, nil
+}
+
+// decideRun pushes or discards the run's commit, or executes or discards its
+// plan, and records the decision
+func (w *Worker) decideRun(ctx context.Context, run *RunRecord, approve bool, reviewer string) error {
+	if err := w.Git.ChangeDirectory(ctx, run.Worktree); err != nil {
+		return fmt.Errorf("failed to change directory: %w", err)
+	}
+	head, err := w.Git.HeadSHA(ctx)
+	if err != nil {
+		return err
+	}
+	if head != run.CommitSHA {
+		return fmt.Errorf("worktree %s is at %s, not at the run's commit %s", run.Worktree, shortSHA(head), shortSHA(run.CommitSHA))
+	}
+
+	if run.PlanFile != "" {
+		return w.decidePlan(ctx, run, approve, reviewer)
+	}
+
+	status := RunStatusSucceeded
+	if approve {
+		err = w.runPhase(ctx, PhasePush, w.Git.Push)
+	} else {
+		status = RunStatusRejected
+		err = w.Git.ResetHard(ctx, run.BaseSHA)
+	}
+	if err != nil {
+		return err
+	}
+
+	run.Status = status
+	run.ReviewedBy = reviewer
+	run.FinishedAt = time.Now()
+	if err := w.saveRun(run); err != nil {
+		return err
+	}
+
+	return w.GitHub.PostComment(ctx, run.PRNumber, formatApprovalDecisionComment(run))
+}
+
+// isTrustedAssociation reports whether an author association may approve runs
+func isTrustedAssociation(association string) bool {
+	for _, trusted := range trustedAssociations {
+		if association == trusted {
+			return true
+		}
+	}
+	return false
+}
+
+// parseApprovalCommand parses "/kratt approve" or "/kratt reject" at the
+// start of a comment, optionally followed by the run ID it applies to
+func parseApprovalCommand(body, runID string) (approve bool, ok bool) {
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	fields := strings.Fields(firstLine)
+	if len(fields) < 2 || len(fields) > 3 {
+		return false, false
+	}
+
+	command := fields[0] + " " + fields[1]
+	if command != ApproveCommand && command != RejectCommand {
+		return false, false
+	}
+	if len(fields) == 3 && fields[2] != runID {
+		return false, false
+	}
+	return command == ApproveCommand, true
+}
+
+// formatApprovalRequestComment asks for approval of a locally committed run
+func formatApprovalRequestComment(run *RunRecord, stat DiffStat) string {
+	var comment strings.Builder
+	comment.WriteString("## Kratt Approval Required\n\n")
+	fmt.Fprintf(&comment, "Run `%s` committed its changes locally as `%s` (%d files, +%d/-%d) but did not push them.\n\n",
+		run.ID, shortSHA(run.CommitSHA), stat.Files, stat.Additions, stat.Deletions)
+	fmt.Fprintf(&comment, "Reply `%s` to push the changes or `%s` to discard them, or run `kratt approve %s` locally.\n",
+		ApproveCommand, RejectCommand, run.ID)
+	return comment.String()
+}
+
+// formatApprovalDecisionComment reports the outcome of an approval decision
+func formatApprovalDecisionComment(run *RunRecord) string {
+	reviewer := " locally"
+	if run.ReviewedBy != "" {
+		reviewer = " by @" + run.ReviewedBy
+	}
+	if run.PlanFile != "" {
+		if run.Status == RunStatusRejected {
+			return fmt.Sprintf("🗑️ **Rejected**%s: the plan of run `%s` will not be executed.", reviewer, run.ID)
+		}
+		return fmt.Sprintf("✅ **Approved**%s: executing the plan of run `%s` step by step.", reviewer, run.ID)
+	}
+	if run.Status == RunStatusRejected {
+		return fmt.Sprintf("🗑️ **Rejected**%s: the changes of run `%s` were discarded.", reviewer, run.ID)
+	}
+	return fmt.Sprintf("✅ **Approved**%s: the changes of run `%s` were pushed as `%s`.", reviewer, run.ID, shortSHA(run.CommitSHA))
+}
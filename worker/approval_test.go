@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerProcessPRRequireApproval(t *testing.T) {
+	fakeGit, fakeGitHub := NewFakeLocalGit(), NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 8, "feature", func(w *Worker) { w.RequireApproval = true })
+
+	if err := w.ProcessPR(context.Background(), 8); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+
+	if fakeGit.GetPushCount() != 0 {
+		t.Error("Expected changes not to be pushed before approval")
+	}
+
+	history, _ := w.Runs.ListRuns()
+	run := history[0]
+	if run.Status != RunStatusAwaitingApproval || run.Worktree != "/fake/repo-feature" || run.CommitSHA == run.BaseSHA {
+		t.Fatalf("Unexpected run record: %+v", run)
+	}
+
+	comments := fakeGitHub.GetComments(8)
+	if len(comments) != 2 || !strings.Contains(comments[1], "kratt approve "+run.ID) {
+		t.Errorf("Expected approval request comment, got %v", comments)
+	}
+
+	// Comments from untrusted users or from before the request are ignored
+	after := time.Now().Add(time.Hour).Format(time.RFC3339)
+	before := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	fakeGitHub.SetPRInfo(8, fmt.Sprintf(`{"headRefName": "feature", "comments": [
+		{"author": {"login": "old"}, "authorAssociation": "OWNER", "body": "/kratt reject", "createdAt": %q},
+		{"author": {"login": "drive-by"}, "authorAssociation": "NONE", "body": "/kratt reject", "createdAt": %q},
+		{"author": {"login": "other"}, "authorAssociation": "MEMBER", "body": "/kratt approve 20990101-000000-000000000-pr1", "createdAt": %q}
+	]}`, before, after, after))
+	if decided, err := w.ResumeRun(context.Background(), run.ID); err != nil || decided {
+		t.Fatalf("Expected no decision, got decided=%v err=%v", decided, err)
+	}
+
+	fakeGitHub.SetPRInfo(8, fmt.Sprintf(`{"headRefName": "feature", "comments": [
+		{"author": {"login": "maintainer"}, "authorAssociation": "MEMBER", "body": "/kratt approve\nLooks good", "createdAt": %q}
+	]}`, after))
+	decided, err := w.ResumeRun(context.Background(), run.ID)
+	if err != nil || !decided {
+		t.Fatalf("Expected approval to be applied, got decided=%v err=%v", decided, err)
+	}
+
+	if fakeGit.GetPushCount() != 1 {
+		t.Error("Expected changes to be pushed after approval")
+	}
+	run, _ = w.Runs.GetRun(run.ID)
+	if run.Status != RunStatusSucceeded || run.ReviewedBy != "maintainer" {
+		t.Errorf("Unexpected run record after approval: %+v", run)
+	}
+	if last := fakeGitHub.GetComments(8)[2]; !strings.Contains(last, "Approved** by @maintainer") {
+		t.Errorf("Unexpected decision comment: %s", last)
+	}
+
+	if err := w.ApproveRun(context.Background(), run.ID, ""); err == nil {
+		t.Error("Expected error approving a run that is no longer awaiting approval")
+	}
+}
+
+func TestWorkerRejectRun(t *testing.T) {
+	fakeGit, fakeGitHub := NewFakeLocalGit(), NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 8, "feature", func(w *Worker) { w.RequireApproval = true })
+
+	if err := w.ProcessPR(context.Background(), 8); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+	history, _ := w.Runs.ListRuns()
+	run := history[0]
+
+	if err := w.RejectRun(context.Background(), run.ID, ""); err != nil {
+		t.Fatalf("RejectRun failed: %v", err)
+	}
+
+	if resets := fakeGit.GetResets(); len(resets) != 1 || resets[0] != run.BaseSHA {
+		t.Errorf("Expected reset to the base commit, got %v", resets)
+	}
+	run, _ = w.Runs.GetRun(run.ID)
+	if fakeGit.GetPushCount() != 0 || run.Status != RunStatusRejected {
+		t.Errorf("Expected rejected run without push, got %+v", run)
+	}
+	if last := fakeGitHub.GetComments(8)[2]; !strings.Contains(last, "Rejected** locally") {
+		t.Errorf("Unexpected decision comment: %s", last)
+	}
+}
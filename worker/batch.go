@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+)
+
+// Outcomes of starting a task in a batch
+const (
+	StartCreated = "created"
+	StartSkipped = "skipped"
+	StartFailed  = "failed"
+)
+
+// StartTask describes a branch and pull request to create with Start. The
+// branch starts from PROptions.Base, or the remote default branch if empty.
+type StartTask struct {
+	Branch       string
+	Instructions string
+	Title        string // Empty means a title derived from the instructions
+	PROptions
+}
+
+// StartResult is the outcome of starting one task of a batch
+type StartResult struct {
+	Task     StartTask
+	Status   string
+	PRNumber int
+	Err      error
+}
+
+// StartBatch starts each task like Start. Tasks whose branch or pull request
+// already exists are skipped, and a failing task does not stop the batch.
+func (w *Worker) StartBatch(ctx context.Context, tasks []StartTask) ([]StartResult, error) {
+	results := make([]StartResult, 0, len(tasks))
+	for _, task := range tasks {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, w.startBatchTask(ctx, task))
+	}
+	return results, nil
+}
+
+// startBatchTask starts a single task of a batch
+func (w *Worker) startBatchTask(ctx context.Context, task StartTask) StartResult {
+	result := StartResult{Task: task, Status: StartFailed}
+
+	exists, err := w.Git.BranchExists(ctx, task.Branch)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to check branch: %w", err)
+		return result
+	}
+	prNumber, err := w.GitHub.FindPR(ctx, task.Branch)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if exists || prNumber != 0 {
+		result.Status = StartSkipped
+		result.PRNumber = prNumber
+		return result
+	}
+
+	pr, err := w.StartWith(ctx, task)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Status = StartCreated
+	result.PRNumber = pr.Number
+	return result
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWorkerStartBatch(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.CreateBranch(context.Background(), "existing", "origin/main", "/fake/repo-existing")
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetBranchPR("has-pr", 5)
+
+	w := &Worker{Git: fakeGit, GitHub: fakeGitHub, Runner: NewFakeCommandRunner()}
+
+	results, err := w.StartBatch(context.Background(), []StartTask{
+		{Branch: "feature-a", Instructions: "Do A"},
+		{Branch: "existing", Instructions: "Do B"},
+		{Branch: "has-pr", Instructions: "Do C"},
+		{Branch: "feature-d", Instructions: "Do D", PROptions: PROptions{Base: "release", Labels: []string{"batch"}, Assignees: []string{"alice"}}},
+	})
+	if err != nil {
+		t.Fatalf("StartBatch failed: %v", err)
+	}
+
+	statuses := []string{StartCreated, StartSkipped, StartSkipped, StartCreated}
+	for i, result := range results {
+		if result.Status != statuses[i] || result.Err != nil {
+			t.Errorf("Task %s: expected %s, got %s (%v)", result.Task.Branch, statuses[i], result.Status, result.Err)
+		}
+	}
+	if results[2].PRNumber != 5 || results[3].PRNumber != 2 {
+		t.Errorf("Expected PR numbers 5 and 2, got %d and %d", results[2].PRNumber, results[3].PRNumber)
+	}
+
+	if start := fakeGit.GetStartPoint("feature-a"); start != "origin/main" {
+		t.Errorf("Expected feature-a to start at origin/main, got %q", start)
+	}
+	if start := fakeGit.GetStartPoint("feature-d"); start != "origin/release" {
+		t.Errorf("Expected feature-d to start at origin/release, got %q", start)
+	}
+
+	prs := fakeGitHub.GetCreatedPRs()
+	if len(prs) != 2 {
+		t.Fatalf("Expected 2 pull requests, got %d", len(prs))
+	}
+	if options := prs[1].Options; options.Base != "release" || options.Labels[0] != "batch" || options.Assignees[0] != "alice" {
+		t.Errorf("Unexpected PR options: %+v", options)
+	}
+}
+
+func TestWorkerStartBatchContinuesAfterFailure(t *testing.T) {
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.FailCreatePR = true
+	w := &Worker{Git: NewFakeLocalGit(), GitHub: fakeGitHub, Runner: NewFakeCommandRunner()}
+
+	results, err := w.StartBatch(context.Background(), []StartTask{
+		{Branch: "feature-a", Instructions: "Do A"},
+		{Branch: "feature-b", Instructions: "Do B"},
+	})
+	if err != nil {
+		t.Fatalf("StartBatch failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Status != StartFailed || results[1].Status != StartFailed || results[1].Err == nil {
+		t.Errorf("Expected both tasks to fail, got %+v", results)
+	}
+}
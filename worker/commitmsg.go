@@ -0,0 +1,251 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultCommitTemplate renders a Conventional Commit header, the agent's
+// summary as body and trailers identifying the run
+const DefaultCommitTemplate = `{{.Type}}{{if .Scope}}({{.Scope}}){{end}}: {{.Subject}}
+{{if .Body}}
+{{.Body}}
+{{end}}
+Kratt-Run: {{.RunID}}
+{{if .Step}}Kratt-Step: {{.Step}}
+{{end}}{{if .Agent}}Kratt-Agent: {{.Agent}}
+{{end}}{{if .PRNumber}}Refs: #{{.PRNumber}}
+{{end}}{{if .CoAuthor}}Co-authored-by: {{.CoAuthor}}
+{{end}}`
+
+// maxCommitHeaderLength bounds the first line of generated commit messages
+const maxCommitHeaderLength = 72
+
+// maxCommitScopeLength is the longest Conventional Commit scope kept in generated headers
+const maxCommitScopeLength = 24
+
+// maxSummaryDiffLength bounds the diff sent to the agent when asking for a commit message
+const maxSummaryDiffLength = 20000
+
+// defaultCommitTemplate is DefaultCommitTemplate, parsed
+var defaultCommitTemplate = template.Must(ParseCommitTemplate(DefaultCommitTemplate))
+
+// conventionalHeaderPattern matches a header such as "fix(parser)!: handle empty input"
+var conventionalHeaderPattern = regexp.MustCompile(`^([a-z]{1,16})(?:\(([^)]+)\))?!?: *(.+)$`)
+
+// commitTypes maps the first word of a summary to a Conventional Commit type
+var commitTypes = map[string]string{
+	"fix": "fix", "fixed": "fix", "fixes": "fix", "resolve": "fix", "resolved": "fix", "correct": "fix", "corrected": "fix",
+	"add": "feat", "added": "feat", "adds": "feat", "implement": "feat", "implemented": "feat", "introduce": "feat", "introduced": "feat", "support": "feat",
+	"test": "test", "tests": "test", "tested": "test",
+	"document": "docs", "documented": "docs", "docs": "docs",
+	"refactor": "refactor", "refactored": "refactor", "rename": "refactor", "renamed": "refactor", "simplify": "refactor", "simplified": "refactor", "extract": "refactor", "extracted": "refactor", "move": "refactor", "moved": "refactor",
+	"remove": "refactor", "removed": "refactor", "clean": "refactor", "cleaned": "refactor",
+	"update": "chore", "updated": "chore", "bump": "chore", "bumped": "chore",
+}
+
+// taskCommitTypes is the commit type of a task when the summary does not suggest one
+var taskCommitTypes = map[string]string{
+	TaskImplement: "feat",
+	TaskFixTests:  "test",
+	TaskReview:    "refactor",
+}
+
+// CommitMessage is the data commit message templates are rendered with
+type CommitMessage struct {
+	Type     string // Conventional Commit type, e.g. "fix"
+	Scope    string // Optional Conventional Commit scope
+	Subject  string // Description in the header
+	Body     string // The agent's summary, if longer than the subject
+	RunID    string
+	Step     int    // Plan step committed, 0 outside plan steps
+	Agent    string // Agent whose work is committed
+	PRNumber int
+	Branch   string
+	CoAuthor string // "Name <email>" of the human who triggered the run
+}
+
+// ParseCommitTemplate parses a text/template for commit messages rendered with a CommitMessage
+func ParseCommitTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("commit").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// newCommitMessage derives the header and body from the agent's summary.
+// A summary starting with a Conventional Commit header is used as is;
+// otherwise the type is guessed from the summary's first word or the task.
+func newCommitMessage(run *RunRecord, agent, summary string) CommitMessage {
+	message := CommitMessage{
+		RunID:    run.ID,
+		Agent:    agent,
+		PRNumber: run.PRNumber,
+		Branch:   run.Branch,
+	}
+
+	summary = strings.TrimSpace(summary)
+	header, body, _ := strings.Cut(summary, "\n")
+	header = strings.TrimSpace(strings.TrimLeft(header, "#*-> "))
+	body = strings.TrimSpace(body)
+
+	if m := conventionalHeaderPattern.FindStringSubmatch(header); m != nil {
+		message.Type, message.Scope, header = m[1], m[2], m[3]
+	} else {
+		firstWord, _, _ := strings.Cut(strings.ToLower(header), " ")
+		message.Type = commitTypes[strings.Trim(firstWord, ",.:;")]
+	}
+	if message.Type == "" {
+		message.Type = taskCommitTypes[run.TaskType]
+	}
+	if message.Type == "" {
+		message.Type = "chore"
+	}
+
+	if header == "" {
+		header = "apply changes from kratt worker"
+	}
+	// A scope taking up most of the header is dropped rather than leaving
+	// no room for the subject
+	if utf8.RuneCountInString(message.Scope) > maxCommitScopeLength {
+		message.Scope = ""
+	}
+	prefix := utf8.RuneCountInString(message.Type) + len(": ")
+	if message.Scope != "" {
+		prefix += utf8.RuneCountInString(message.Scope) + len("()")
+	}
+	subject := truncateAtWord(strings.TrimRight(header, "."), maxCommitHeaderLength-prefix)
+	message.Subject = lowerFirst(subject)
+
+	// Keep the full summary when the header had to be shortened
+	if subject != strings.TrimRight(header, ".") {
+		body = strings.TrimSpace(header + "\n\n" + body)
+	}
+	message.Body = body
+	return message
+}
+
+// truncateAtWord shortens s to at most max characters at a word boundary, marking the cut with "…"
+func truncateAtWord(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	head := string(runes[:clamp(max-1, 1, len(runes))])
+	cut := strings.LastIndex(head, " ")
+	if cut <= 0 {
+		cut = len(head)
+	}
+	return strings.TrimRight(head[:cut], " ,;:.") + "…"
+}
+
+// lowerFirst lowercases the first letter of s unless it starts an acronym such as "API"
+func lowerFirst(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	second, _ := utf8.DecodeRuneInString(s[size:])
+	if first == utf8.RuneError || unicode.IsUpper(second) {
+		return s
+	}
+	return string(unicode.ToLower(first)) + s[size:]
+}
+
+// clamp limits n to the range [low, high]
+func clamp(n, low, high int) int {
+	return max(low, min(n, high))
+}
+
+// commitMessage renders the message for committing an agent's work. Without
+// a summary from the agent and with SummarizeDiff, the agent is asked to
+// summarize the staged diff first.
+func (w *Worker) commitMessage(ctx context.Context, run *RunRecord, profile AgentProfile, result *AgentResult) (string, error) {
+	var summary string
+	if result != nil {
+		summary = result.Summary
+	}
+	if strings.TrimSpace(summary) == "" && w.SummarizeDiff && profile.Agent != nil {
+		// A failed summary only costs the commit message its description
+		w.runPhase(ctx, PhaseAgent, func(ctx context.Context) error {
+			var err error
+			summary, err = w.summarizeDiff(ctx, profile.Agent)
+			return err
+		})
+	}
+	message := newCommitMessage(run, profile.Name, summary)
+	message.CoAuthor = w.CoAuthor
+	return w.renderCommitMessage(message)
+}
+
+// planCommitMessage renders the message for committing plan mode work: the
+// plan itself when step is 0, otherwise the numbered plan step
+func (w *Worker) planCommitMessage(run *RunRecord, step int, summary string) (string, error) {
+	message := newCommitMessage(run, run.Agent, summary)
+	message.Step = step
+	message.CoAuthor = w.CoAuthor
+	return w.renderCommitMessage(message)
+}
+
+// summarizeDiff asks the agent for a one-line commit message describing the staged changes
+func (w *Worker) summarizeDiff(ctx context.Context, agent Agent) (string, error) {
+	if err := w.Git.StageAll(ctx); err != nil {
+		return "", err
+	}
+	diff, err := w.Git.StagedDiff(ctx, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if len(diff) > maxSummaryDiffLength {
+		diff = diff[:maxSummaryDiffLength] + "\n[diff truncated]\n"
+	}
+
+	result, err := w.runAgent(ctx, agent, summaryPrompt(diff))
+	if err != nil {
+		return "", err
+	}
+	if result == nil {
+		return "", fmt.Errorf("agent %s did not report a summary", agent.Name())
+	}
+	return result.Summary, nil
+}
+
+// summaryPrompt asks for a commit message for a diff
+func summaryPrompt(diff string) string {
+	var b strings.Builder
+	b.WriteString("Write a commit message for the staged changes below. ")
+	b.WriteString("Reply with a single line in Conventional Commit format, such as \"fix(parser): handle empty input\". ")
+	b.WriteString("Do not change any files.\n\n")
+	b.WriteString("<diff>\n")
+	b.WriteString(diff)
+	b.WriteString("</diff>")
+	return b.String()
+}
+
+// renderCommitMessage renders a commit message with CommitTemplate, or
+// DefaultCommitTemplate if none is set. Secrets in the agent's summary are
+// redacted, as they would be in a comment.
+func (w *Worker) renderCommitMessage(message CommitMessage) (string, error) {
+	tmpl := w.CommitTemplate
+	if tmpl == nil {
+		tmpl = defaultCommitTemplate
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, message); err != nil {
+		return "", fmt.Errorf("failed to render commit message: %w", err)
+	}
+	return w.redactor().Redact(strings.TrimSpace(b.String())) + "\n", nil
+}
+
+// findProfile returns the profile with the given name from a chain
+func findProfile(chain []AgentProfile, name string) AgentProfile {
+	for _, profile := range chain {
+		if profile.Name == name {
+			return profile
+		}
+	}
+	return AgentProfile{Name: name}
+}
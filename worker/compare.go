@@ -0,0 +1,241 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ComparisonEntry records the outcome of one agent in compare mode
+type ComparisonEntry struct {
+	Agent      string        `json:"agent"`
+	Branch     string        `json:"branch"`
+	AgentError string        `json:"agent_error,omitempty"`
+	LintPassed bool          `json:"lint_passed"`
+	TestPassed bool          `json:"test_passed"`
+	Diff       DiffStat      `json:"diff"`
+	Duration   time.Duration `json:"duration"`
+	PushError  string        `json:"push_error,omitempty"`
+	Summary    string        `json:"summary,omitempty"`
+}
+
+// ComparePR runs each named agent on the pull request in its own scratch
+// worktree, starting from the same commit, and posts a comparison comment.
+// Each attempt is pushed to its own side branch so reviewers can pick one.
+func (w *Worker) ComparePR(ctx context.Context, prNumber int, agentNames []string) error {
+	run, err := w.startRun(prNumber)
+	if err != nil {
+		return err
+	}
+	run.Route = "compare"
+	return w.finishRun(ctx, run, w.comparePR(ctx, run, prNumber, agentNames))
+}
+
+// comparePR runs the steps of ComparePR, filling in the run record
+func (w *Worker) comparePR(ctx context.Context, run *RunRecord, prNumber int, agentNames []string) error {
+	if len(agentNames) < 2 {
+		return fmt.Errorf("compare mode needs at least two agents, got %d", len(agentNames))
+	}
+
+	profiles := []AgentProfile{}
+	for _, name := range agentNames {
+		profile, ok := w.agentProfile(name)
+		if !ok {
+			return fmt.Errorf("unknown agent %q", name)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	prInfo, err := w.GitHub.GetPRInfo(ctx, prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get PR info: %w", err)
+	}
+
+	branch, err := w.extractBranchFromPRInfo(prInfo)
+	if err != nil {
+		return fmt.Errorf("failed to extract branch from PR info: %w", err)
+	}
+	run.Branch = branch
+
+	if err := w.enterWorktree(ctx, run); err != nil {
+		return err
+	}
+	prPath := run.Worktree
+
+	startSHA, err := w.Git.HeadSHA(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get starting commit: %w", err)
+	}
+
+	prompt := w.generatePrompt(prInfo)
+	for _, profile := range profiles {
+		entry, err := w.compareAgent(ctx, run, profile, branch, startSHA, prompt)
+		if err != nil {
+			w.Git.ChangeDirectory(ctx, prPath)
+			return fmt.Errorf("failed to compare agent %s: %w", profile.Name, err)
+		}
+		run.Comparison = append(run.Comparison, entry)
+
+		if err := w.Git.ChangeDirectory(ctx, prPath); err != nil {
+			return fmt.Errorf("failed to change directory: %w", err)
+		}
+	}
+
+	commentBody := formatComparisonComment(startSHA, run.Comparison)
+	if err := w.GitHub.PostComment(ctx, prNumber, commentBody); err != nil {
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+
+	return nil
+}
+
+// compareAgent runs a single agent in a scratch worktree created from startSHA
+// and pushes its changes to a side branch. Agent, lint and test failures are
+// recorded in the entry; only cancellation and failures to manage the
+// worktree are returned.
+func (w *Worker) compareAgent(ctx context.Context, run *RunRecord, profile AgentProfile, branch, startSHA, prompt string) (ComparisonEntry, error) {
+	entry := ComparisonEntry{
+		Agent:  profile.Name,
+		Branch: compareBranchName(branch, profile.Name),
+	}
+
+	path, err := w.Git.GetWorktreePath(ctx, entry.Branch)
+	if err != nil {
+		return entry, fmt.Errorf("failed to get worktree path: %w", err)
+	}
+	if err := w.Git.CreateWorktreeAt(ctx, entry.Branch, path, startSHA); err != nil {
+		return entry, err
+	}
+	defer func() {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		w.Git.RemoveWorktree(cleanupCtx, path)
+	}()
+
+	if err := w.Git.ChangeDirectory(ctx, path); err != nil {
+		return entry, fmt.Errorf("failed to change directory: %w", err)
+	}
+
+	started := time.Now()
+	var result *AgentResult
+	agentErr := w.runPhase(ctx, PhaseAgent, func(ctx context.Context) error {
+		var attempt AgentAttempt
+		var err error
+		result, attempt, err = w.runAgentProfile(ctx, profile, prompt)
+		run.AgentAttempts = append(run.AgentAttempts, attempt)
+		return err
+	})
+	if agentErr != nil {
+		entry.AgentError = agentErr.Error()
+	}
+	if result != nil {
+		entry.Summary = result.Summary
+	}
+
+	_, lintErr := w.runCheck(ctx, PhaseLint, w.LintCommand)
+	_, testErr := w.runCheck(ctx, PhaseTest, w.TestCommand)
+	entry.LintPassed = lintErr == nil
+	entry.TestPassed = testErr == nil
+	entry.Duration = time.Since(started).Round(time.Second)
+
+	if err := ctx.Err(); err != nil {
+		return entry, err
+	}
+
+	// Summarizing the diff may run the agent again, so the message is
+	// produced before its changes are checked
+	message, err := w.commitMessage(ctx, run, profile, result)
+	if err != nil {
+		return entry, err
+	}
+
+	if err := w.Git.StageAll(ctx); err != nil {
+		return entry, err
+	}
+	entry.Diff, err = w.Git.DiffStat(ctx, startSHA)
+	if err != nil {
+		return entry, err
+	}
+
+	commits, violations, findings, err := w.checkAgentWork(ctx, startSHA)
+	if err != nil {
+		return entry, err
+	}
+	run.SecretFindings = append(run.SecretFindings, findings...)
+	if err := changesError(violations, findings); err != nil {
+		entry.PushError = err.Error()
+		return entry, nil
+	}
+
+	if err := w.pushAgentWork(ctx, startSHA, commits, message); err != nil {
+		entry.PushError = err.Error()
+	}
+
+	return entry, nil
+}
+
+// compareBranchName returns the side branch name for an agent's attempt
+func compareBranchName(branch, agent string) string {
+	return fmt.Sprintf("%s-kratt-%s", branch, agent)
+}
+
+// formatComparisonComment formats the compare mode results into a comment
+func formatComparisonComment(startSHA string, entries []ComparisonEntry) string {
+	var comment strings.Builder
+
+	comment.WriteString("## Kratt Agent Comparison\n\n")
+	comment.WriteString(fmt.Sprintf("All agents started from `%s`.\n\n", shortSHA(startSHA)))
+
+	comment.WriteString("| Agent | Agent run | Lint | Tests | Diff | Duration | Branch |\n")
+	comment.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, entry := range entries {
+		branch := fmt.Sprintf("`%s`", entry.Branch)
+		if entry.PushError != "" {
+			branch += " (push failed)"
+		}
+		comment.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %d files, +%d/-%d | %s | %s |\n",
+			entry.Agent,
+			passFail(entry.AgentError == ""),
+			passFail(entry.LintPassed),
+			passFail(entry.TestPassed),
+			entry.Diff.Files, entry.Diff.Additions, entry.Diff.Deletions,
+			entry.Duration,
+			branch,
+		))
+	}
+
+	for _, entry := range entries {
+		if entry.Summary == "" && entry.AgentError == "" {
+			continue
+		}
+		comment.WriteString(fmt.Sprintf("\n### %s\n", entry.Agent))
+		if entry.Summary != "" {
+			comment.WriteString(entry.Summary)
+			comment.WriteString("\n")
+		}
+		if entry.AgentError != "" {
+			comment.WriteString("```\n")
+			comment.WriteString(entry.AgentError)
+			comment.WriteString("\n```\n")
+		}
+	}
+
+	return comment.String()
+}
+
+// passFail renders a boolean outcome as an emoji
+func passFail(ok bool) string {
+	if ok {
+		return "✅"
+	}
+	return "❌"
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerComparePR(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetHeadSHA("abcdef0123456789")
+	fakeGit.SetDiffStat(DiffStat{Files: 2, Additions: 10, Deletions: 3})
+
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(9, `{"headRefName": "feature"}`)
+
+	fakeRunner := NewFakeCommandRunner()
+	fakeRunner.SetResponse("go test ./...", []byte("FAIL"), fmt.Errorf("exit status 1"))
+
+	agentA := NewFakeAgent("a")
+	agentA.Result = &AgentResult{Summary: "Refactored the parser"}
+	agentB := NewFakeAgent("b")
+	agentB.Err = fmt.Errorf("rate limited")
+
+	runs := NewFakeRunStore()
+	w := &Worker{
+		Instructions: "You are a helpful AI assistant.",
+		LintCommand:  []string{"go", "vet", "./..."},
+		TestCommand:  []string{"go", "test", "./..."},
+		Deadline:     5 * time.Second,
+		Agents: []AgentProfile{
+			{Name: "a", Agent: agentA},
+			{Name: "b", Agent: agentB},
+		},
+		Runs:   runs,
+		Git:    fakeGit,
+		GitHub: fakeGitHub,
+		Runner: fakeRunner,
+	}
+
+	if err := w.ComparePR(context.Background(), 9, []string{"a", "b"}); err != nil {
+		t.Fatalf("ComparePR failed: %v", err)
+	}
+
+	if len(agentA.GetPrompts()) != 1 || len(agentB.GetPrompts()) != 1 {
+		t.Error("Expected each agent to run once")
+	}
+
+	commits := fakeGit.GetCommits()
+	if len(commits) != 2 || !strings.Contains(commits[0], "Kratt-Agent: a") || !strings.Contains(commits[1], "Kratt-Agent: b") {
+		t.Errorf("Expected one commit per agent, got %v", commits)
+	}
+
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "feature-kratt-a"); exists {
+		t.Error("Expected scratch worktree to be removed")
+	}
+	if fakeGit.GetCurrentDir() != "/fake/repo-feature" {
+		t.Errorf("Expected to return to the PR worktree, got %s", fakeGit.GetCurrentDir())
+	}
+
+	comment := fakeGitHub.GetComments(9)[0]
+	for _, want := range []string{
+		"started from `abcdef0`",
+		"| a | ✅ | ✅ | ❌ | 2 files, +10/-3 |",
+		"| b | ❌ | ✅ | ❌ |",
+		"`feature-kratt-b`",
+		"Refactored the parser",
+		"rate limited",
+	} {
+		if !strings.Contains(comment, want) {
+			t.Errorf("Expected comment to contain %q, got:\n%s", want, comment)
+		}
+	}
+
+	history, _ := runs.ListRuns()
+	if len(history) != 1 || len(history[0].Comparison) != 2 || history[0].Status != RunStatusSucceeded {
+		t.Errorf("Expected comparison to be recorded, got %+v", history)
+	}
+}
+
+func TestWorkerComparePRUnknownAgent(t *testing.T) {
+	w := &Worker{
+		Agents: []AgentProfile{{Name: "a", Agent: NewFakeAgent("a")}},
+		Git:    NewFakeLocalGit(),
+		GitHub: NewFakeGitHub(),
+		Runner: NewFakeCommandRunner(),
+	}
+
+	err := w.ComparePR(context.Background(), 9, []string{"a", "missing"})
+	if err == nil || !strings.Contains(err.Error(), `unknown agent "missing"`) {
+		t.Errorf("Expected unknown agent error, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Dirty worktree policies: what a run does with a worktree that an earlier
+// run left with uncommitted changes, an unfinished operation, a detached HEAD
+// or commits that are not on the remote branch
+const (
+	DirtyAbort = "abort" // Stop the run and report the worktree's state
+	DirtyStash = "stash" // Stash changes, abort unfinished operations and check out the branch; local commits still abort
+	DirtyReset = "reset" // Discard changes, untracked files and local commits and reset to the remote branch
+)
+
+// WorktreeStatus describes the state of the current worktree relative to a branch
+type WorktreeStatus struct {
+	Branch    string   // Checked out branch; empty for a detached HEAD
+	Changes   []string // Uncommitted changes and untracked files as git status --porcelain lines
+	Operation string   // Unfinished rebase, am, merge, cherry-pick or revert
+	Ahead     int      // Commits not on origin/<branch>
+	Behind    int      // Commits on origin/<branch> that are not checked out
+}
+
+// Problems describes what keeps the worktree from being used for a run on branch
+func (s WorktreeStatus) Problems(branch string) []string {
+	var problems []string
+	if len(s.Changes) > 0 {
+		problems = append(problems, fmt.Sprintf("%d uncommitted changes", len(s.Changes)))
+	}
+	if s.Operation != "" {
+		problems = append(problems, s.Operation+" in progress")
+	}
+	if s.Branch == "" {
+		problems = append(problems, "detached HEAD")
+	} else if s.Branch != branch {
+		problems = append(problems, fmt.Sprintf("%s checked out instead of %s", s.Branch, branch))
+	}
+	if s.Ahead > 0 && s.Behind > 0 {
+		problems = append(problems, fmt.Sprintf("diverged from origin/%s (%d ahead, %d behind)", branch, s.Ahead, s.Behind))
+	} else if s.Ahead > 0 {
+		problems = append(problems, fmt.Sprintf("%d commits not on origin/%s", s.Ahead, branch))
+	}
+	return problems
+}
+
+// DirtyWorktreeError is returned when a run's worktree cannot be used as is
+// and the dirty worktree policy does not allow recovering it
+type DirtyWorktreeError struct {
+	Branch   string
+	Path     string
+	Problems []string
+	Changes  []string
+}
+
+func (e *DirtyWorktreeError) Error() string {
+	return fmt.Sprintf("worktree of %s is dirty: %s", e.Branch, strings.Join(e.Problems, ", "))
+}
+
+// checkAwaitingApproval refuses to touch the worktree at path while a run
+// awaiting approval keeps its unpushed commit there: a later run would
+// otherwise abort on it, or reset it away and leave the run stuck
+func (w *Worker) checkAwaitingApproval(path string) error {
+	if w.Runs == nil {
+		return nil
+	}
+	runs, err := w.Runs.ListRuns()
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if run.Status == RunStatusAwaitingApproval && run.Worktree == path {
+			return fmt.Errorf("worktree %s holds run %s, which awaits approval; approve or reject it first with kratt approve %s or kratt reject %s", path, run.ID, run.ID, run.ID)
+		}
+	}
+	return nil
+}
+
+// checkWorktree inspects the current worktree of the run's branch before the
+// agent runs, recovers it according to the DirtyWorktree policy and
+// fast-forwards a branch that is only behind the remote. The remote branch
+// must have been fetched.
+func (w *Worker) checkWorktree(ctx context.Context, run *RunRecord) error {
+	branch := run.Branch
+	status, err := w.Git.Status(ctx, branch)
+	if err != nil {
+		return fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	if problems := status.Problems(branch); len(problems) > 0 {
+		dirtyErr := &DirtyWorktreeError{Branch: branch, Path: run.Worktree, Problems: problems, Changes: status.Changes}
+		switch w.DirtyWorktree {
+		case DirtyStash:
+			if status.Ahead > 0 {
+				// Stashing cannot keep local commits safe
+				return dirtyErr
+			}
+			err = w.stashWorktree(ctx, run, status)
+		case DirtyReset:
+			err = w.discardWorktree(ctx, branch, status)
+		case DirtyAbort, "":
+			return dirtyErr
+		default:
+			return fmt.Errorf("unknown dirty worktree policy %q", w.DirtyWorktree)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to recover worktree: %w", err)
+		}
+		run.Recovered = problems
+
+		status, err = w.Git.Status(ctx, branch)
+		if err != nil {
+			return fmt.Errorf("failed to get worktree status: %w", err)
+		}
+		if problems := status.Problems(branch); len(problems) > 0 {
+			dirtyErr.Problems = problems
+			dirtyErr.Changes = status.Changes
+			return dirtyErr
+		}
+	}
+
+	if status.Behind > 0 {
+		if err := w.Git.ResetHard(ctx, "origin/"+branch); err != nil {
+			return fmt.Errorf("failed to fast-forward worktree: %w", err)
+		}
+	}
+	return nil
+}
+
+// stashWorktree aborts an unfinished operation, stashes changes and checks out the branch
+func (w *Worker) stashWorktree(ctx context.Context, run *RunRecord, status WorktreeStatus) error {
+	if status.Operation != "" {
+		if err := w.Git.AbortOperation(ctx, status.Operation); err != nil {
+			return err
+		}
+	}
+	if len(status.Changes) > 0 {
+		if err := w.Git.Stash(ctx, "kratt: leftovers found before run "+run.ID); err != nil {
+			return err
+		}
+	}
+	if status.Branch != run.Branch {
+		return w.Git.CheckoutBranch(ctx, run.Branch)
+	}
+	return nil
+}
+
+// discardWorktree aborts an unfinished operation, discards all changes and
+// resets the branch to the remote branch
+func (w *Worker) discardWorktree(ctx context.Context, branch string, status WorktreeStatus) error {
+	if status.Operation != "" {
+		if err := w.Git.AbortOperation(ctx, status.Operation); err != nil {
+			return err
+		}
+	}
+	if err := w.Git.ResetHard(ctx, "HEAD"); err != nil {
+		return err
+	}
+	if err := w.Git.Clean(ctx); err != nil {
+		return err
+	}
+	if status.Branch != branch {
+		if err := w.Git.CheckoutBranch(ctx, branch); err != nil {
+			return err
+		}
+	}
+	return w.Git.ResetHard(ctx, "origin/"+branch)
+}
+
+// maxReportedChanges bounds the uncommitted changes listed in a dirty worktree comment
+const maxReportedChanges = 20
+
+// formatDirtyWorktreeComment reports a run stopped by a dirty worktree
+func formatDirtyWorktreeComment(err *DirtyWorktreeError) string {
+	var comment strings.Builder
+	comment.WriteString("## Kratt Worker Results\n\n")
+	fmt.Fprintf(&comment, "🧹 **Dirty worktree:** the worktree of `%s` was left in a state the agent should not run in:\n\n", err.Branch)
+	for _, problem := range err.Problems {
+		fmt.Fprintf(&comment, "- %s\n", problem)
+	}
+	if len(err.Changes) > 0 {
+		comment.WriteString("\n```\n")
+		for i, change := range err.Changes {
+			if i == maxReportedChanges {
+				fmt.Fprintf(&comment, "... and %d more\n", len(err.Changes)-maxReportedChanges)
+				break
+			}
+			comment.WriteString(change + "\n")
+		}
+		comment.WriteString("```\n")
+	}
+	fmt.Fprintf(&comment, "\nClean up `%s`, run `kratt worktree reset %s`, or set the dirty worktree policy to `stash` or `reset`.\n", err.Path, err.Branch)
+	return comment.String()
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkerAbortsOnDirtyWorktree(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetStatus(WorktreeStatus{Branch: "feature", Changes: []string{"?? leftover.go"}, Operation: "rebase"})
+	fakeGitHub := NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+
+	err := w.ProcessPR(context.Background(), 7)
+	var dirtyErr *DirtyWorktreeError
+	if !errors.As(err, &dirtyErr) {
+		t.Fatalf("Expected DirtyWorktreeError, got %v", err)
+	}
+	if len(dirtyErr.Problems) != 2 {
+		t.Errorf("Expected changes and rebase to be reported, got %v", dirtyErr.Problems)
+	}
+	comments := fakeGitHub.GetComments(7)
+	if len(comments) != 1 || !strings.Contains(comments[0], "leftover.go") || !strings.Contains(comments[0], "rebase in progress") {
+		t.Errorf("Expected dirty worktree report, got %v", comments)
+	}
+	if len(fakeGit.GetCommits()) != 0 {
+		t.Error("Expected nothing to be committed")
+	}
+}
+
+func TestWorkerKeepsWorktreeAwaitingApproval(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetStatus(WorktreeStatus{Branch: "feature", Ahead: 1})
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature", func(w *Worker) { w.DirtyWorktree = DirtyReset })
+	w.Runs.SaveRun(&RunRecord{ID: "r1", PRNumber: 7, Branch: "feature", Worktree: "/fake/repo-feature", Status: RunStatusAwaitingApproval})
+
+	if err := w.ProcessPR(context.Background(), 7); err == nil || !strings.Contains(err.Error(), "run r1, which awaits approval") {
+		t.Fatalf("Expected run awaiting approval to block the worktree, got %v", err)
+	}
+	if len(fakeGit.GetResets()) != 0 {
+		t.Errorf("Expected the awaiting commit to be kept, got resets %v", fakeGit.GetResets())
+	}
+
+	w.ResetWorktrees = true
+	fakeGit.CreateWorktree(context.Background(), "feature", "/fake/repo-feature")
+	if err := w.ResetWorktree(context.Background(), "feature"); err == nil || !strings.Contains(err.Error(), "awaits approval") {
+		t.Errorf("Expected ResetWorktree to be refused, got %v", err)
+	}
+	if len(fakeGit.GetResets()) != 0 {
+		t.Errorf("Expected the awaiting commit to be kept, got resets %v", fakeGit.GetResets())
+	}
+}
+
+func TestWorkerStashesDirtyWorktree(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetStatus(WorktreeStatus{Changes: []string{" M main.go"}, Operation: "merge"})
+	fakeGitHub := NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature", func(w *Worker) { w.DirtyWorktree = DirtyStash })
+
+	if err := w.ProcessPR(context.Background(), 7); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+	if aborted := fakeGit.GetAbortedOperations(); len(aborted) != 1 || aborted[0] != "merge" {
+		t.Errorf("Expected merge to be aborted, got %v", aborted)
+	}
+	if len(fakeGit.GetStashes()) != 1 {
+		t.Errorf("Expected changes to be stashed, got %v", fakeGit.GetStashes())
+	}
+
+	runs, _ := w.Runs.ListRuns()
+	if len(runs) != 1 || len(runs[0].Recovered) != 3 {
+		t.Errorf("Expected changes, merge and detached HEAD to be recorded, got %+v", runs)
+	}
+	if comments := fakeGitHub.GetComments(7); len(comments) != 1 || !strings.Contains(comments[0], "Recovered worktree") {
+		t.Errorf("Expected recovery to be noted in the results comment, got %v", comments)
+	}
+}
+
+func TestWorkerStashKeepsLocalCommits(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetStatus(WorktreeStatus{Branch: "feature", Ahead: 2, Behind: 1})
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature", func(w *Worker) { w.DirtyWorktree = DirtyStash })
+
+	err := w.ProcessPR(context.Background(), 7)
+	var dirtyErr *DirtyWorktreeError
+	if !errors.As(err, &dirtyErr) || !strings.Contains(dirtyErr.Problems[0], "diverged") {
+		t.Fatalf("Expected divergence to abort the run, got %v", err)
+	}
+	if len(fakeGit.GetResets()) != 0 {
+		t.Errorf("Expected local commits to be kept, got resets %v", fakeGit.GetResets())
+	}
+}
+
+func TestWorkerResetsDirtyWorktree(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetStatus(WorktreeStatus{Branch: "feature", Changes: []string{"?? leftover.go"}, Ahead: 1})
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature", func(w *Worker) { w.DirtyWorktree = DirtyReset })
+
+	if err := w.ProcessPR(context.Background(), 7); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+	resets := fakeGit.GetResets()
+	if len(resets) != 2 || resets[0] != "HEAD" || resets[1] != "origin/feature" {
+		t.Errorf("Expected reset of changes and to origin/feature, got %v", resets)
+	}
+}
+
+func TestWorkerFastForwardsCleanWorktree(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetStatus(WorktreeStatus{Branch: "feature", Behind: 3})
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature")
+
+	if err := w.ProcessPR(context.Background(), 7); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+	if resets := fakeGit.GetResets(); len(resets) != 1 || resets[0] != "origin/feature" {
+		t.Errorf("Expected fast-forward to origin/feature, got %v", resets)
+	}
+}
+
+func TestGitRunnerStatus(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	tmp := t.TempDir()
+	seed := filepath.Join(tmp, "seed")
+	if err := os.Mkdir(seed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initEvalRepo(ctx, seed); err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(tmp, "repo")
+	if err := runGit(ctx, tmp, "clone", "--quiet", seed, repo); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "branch", "--show-current")
+	cmd.Dir = repo
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := strings.TrimSpace(string(output))
+	if err := runGit(ctx, repo, "-c", "user.name=test", "-c", "user.email=test@localhost", "commit", "--quiet", "--allow-empty", "-m", "Local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "leftover.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	original, _ := os.Getwd()
+	defer os.Chdir(original)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := (&GitRunner{}).Status(ctx, branch)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Branch != branch || status.Ahead != 1 || status.Behind != 0 || status.Operation != "" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if len(status.Changes) != 1 || status.Changes[0] != "?? leftover.txt" {
+		t.Errorf("Expected untracked leftover, got %v", status.Changes)
+	}
+}
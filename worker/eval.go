@@ -0,0 +1,360 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// evalBranch is the branch each evaluation task's pull request is opened from
+const evalBranch = "kratt-eval"
+
+// evalPRNumber is the pull request number used with the fake forge
+const evalPRNumber = 1
+
+// EvalTask is a fixture task for offline agent evaluation
+type EvalTask struct {
+	Name        string   `json:"name"`
+	Instruction string   `json:"instruction"`
+	Verify      []string `json:"verify"`
+	// Repo is a git bundle (*.bundle) or a plain directory relative to the task directory
+	Repo string `json:"repo"`
+
+	dir string
+}
+
+// EvalTaskResult is the scored outcome of one evaluation task
+type EvalTaskResult struct {
+	Name         string        `json:"name"`
+	Success      bool          `json:"success"`
+	Error        string        `json:"error,omitempty"`
+	VerifyOutput string        `json:"verify_output,omitempty"`
+	Duration     time.Duration `json:"duration"`
+	Diff         DiffStat      `json:"diff"`
+}
+
+// EvalReport is the scored report of an evaluation
+type EvalReport struct {
+	StartedAt   time.Time        `json:"started_at"`
+	Tasks       []EvalTaskResult `json:"tasks"`
+	Succeeded   int              `json:"succeeded"`
+	SuccessRate float64          `json:"success_rate"`
+	Duration    time.Duration    `json:"duration"`
+}
+
+// LoadEvalTasks loads every task in the subdirectories of dir; each task
+// directory holds a task.json file
+func LoadEvalTasks(dir string) ([]EvalTask, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures directory %s: %w", dir, err)
+	}
+
+	tasks := []EvalTask{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		taskDir := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(filepath.Join(taskDir, "task.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read task %s: %w", entry.Name(), err)
+		}
+
+		var task EvalTask
+		if err := json.Unmarshal(content, &task); err != nil {
+			return nil, fmt.Errorf("failed to parse task %s: %w", entry.Name(), err)
+		}
+		if task.Name == "" {
+			task.Name = entry.Name()
+		}
+		if task.Repo == "" {
+			task.Repo = "repo"
+		}
+		if task.Instruction == "" {
+			return nil, fmt.Errorf("task %s has no instruction", task.Name)
+		}
+		if len(task.Verify) == 0 {
+			return nil, fmt.Errorf("task %s has no verification command", task.Name)
+		}
+		task.dir = taskDir
+		tasks = append(tasks, task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks, nil
+}
+
+// Evaluator runs the worker pipeline against fixture tasks in temporary
+// local repositories with a fake forge
+type Evaluator struct {
+	// Worker is the template configuration; Git and GitHub are replaced for
+	// each task and Runner defaults to ExecRunner
+	Worker *Worker
+}
+
+// Evaluate runs all tasks and returns the scored report
+func (e *Evaluator) Evaluate(ctx context.Context, tasks []EvalTask) (*EvalReport, error) {
+	originalDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	defer os.Chdir(originalDir)
+
+	report := &EvalReport{StartedAt: time.Now(), Tasks: []EvalTaskResult{}}
+	for _, task := range tasks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := e.evaluateTask(ctx, task)
+		if err := os.Chdir(originalDir); err != nil {
+			return nil, fmt.Errorf("failed to restore working directory: %w", err)
+		}
+
+		report.Tasks = append(report.Tasks, result)
+		report.Duration += result.Duration
+		if result.Success {
+			report.Succeeded++
+		}
+	}
+
+	if len(report.Tasks) > 0 {
+		report.SuccessRate = float64(report.Succeeded) / float64(len(report.Tasks))
+	}
+	return report, nil
+}
+
+// evaluateTask sets up a temporary repository for the task, processes its
+// pull request and runs the verification command in the resulting worktree
+func (e *Evaluator) evaluateTask(ctx context.Context, task EvalTask) EvalTaskResult {
+	result := EvalTaskResult{Name: task.Name}
+
+	tmp, err := os.MkdirTemp("", "kratt-eval-*")
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to create temporary directory: %v", err)
+		return result
+	}
+	defer os.RemoveAll(tmp)
+
+	repoDir, startSHA, err := setupEvalRepo(ctx, task, tmp)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if err := os.Chdir(repoDir); err != nil {
+		result.Error = fmt.Sprintf("failed to change directory: %v", err)
+		return result
+	}
+
+	git := &GitRunner{}
+	forge := NewFakeGitHub()
+	prInfo, _ := json.Marshal(map[string]any{
+		"title":       task.Name,
+		"body":        task.Instruction,
+		"headRefName": evalBranch,
+		"comments":    []any{},
+	})
+	forge.SetPRInfo(evalPRNumber, string(prInfo))
+
+	w := *e.Worker
+	w.Git = git
+	w.GitHub = forge
+	if w.Runner == nil {
+		w.Runner = &ExecRunner{}
+	}
+	w.Runs = nil
+
+	started := time.Now()
+	processErr := w.ProcessPR(ctx, evalPRNumber)
+	result.Duration = time.Since(started).Round(time.Millisecond)
+
+	if err := os.Chdir(repoDir); err != nil {
+		result.Error = fmt.Sprintf("failed to change directory: %v", err)
+		return result
+	}
+	worktree, err := git.GetWorktreePath(ctx, evalBranch)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if err := os.Chdir(worktree); err != nil {
+		result.Error = fmt.Sprintf("failed to change directory: %v", err)
+		return result
+	}
+
+	if err := git.StageAll(ctx); err == nil {
+		result.Diff, _ = git.DiffStat(ctx, startSHA)
+	}
+
+	if processErr != nil {
+		result.Error = processErr.Error()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.Deadline)
+	defer cancel()
+	output, verifyErr := w.Runner.RunWithOutput(ctx, task.Verify[0], task.Verify[1:]...)
+	result.VerifyOutput = string(output)
+	if verifyErr != nil {
+		result.Error = fmt.Sprintf("verification failed: %v", verifyErr)
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// setupEvalRepo creates a bare origin and a clone with the evaluation branch,
+// pushed like a pull request's branch, inside tmp, returning the clone's path and starting commit
+func setupEvalRepo(ctx context.Context, task EvalTask, tmp string) (string, string, error) {
+	source := filepath.Join(task.dir, task.Repo)
+	origin := filepath.Join(tmp, "origin.git")
+
+	if strings.HasSuffix(source, ".bundle") {
+		if err := runGit(ctx, tmp, "clone", "--bare", source, origin); err != nil {
+			return "", "", fmt.Errorf("failed to clone bundle %s: %w", source, err)
+		}
+	} else {
+		seed := filepath.Join(tmp, "seed")
+		if err := copyDir(source, seed); err != nil {
+			return "", "", fmt.Errorf("failed to copy fixture repository %s: %w", source, err)
+		}
+		if err := initEvalRepo(ctx, seed); err != nil {
+			return "", "", err
+		}
+		if err := runGit(ctx, tmp, "clone", "--bare", seed, origin); err != nil {
+			return "", "", fmt.Errorf("failed to create origin repository: %w", err)
+		}
+	}
+
+	repoDir := filepath.Join(tmp, "repo")
+	if err := runGit(ctx, tmp, "clone", origin, repoDir); err != nil {
+		return "", "", fmt.Errorf("failed to clone origin repository: %w", err)
+	}
+
+	for _, args := range [][]string{
+		{"config", "user.name", "kratt eval"},
+		{"config", "user.email", "kratt-eval@localhost"},
+		{"branch", evalBranch},
+		{"push", "--quiet", "origin", evalBranch},
+	} {
+		if err := runGit(ctx, repoDir, args...); err != nil {
+			return "", "", fmt.Errorf("failed to prepare repository: %w", err)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get starting commit: %w", err)
+	}
+
+	return repoDir, strings.TrimSpace(string(output)), nil
+}
+
+// initEvalRepo turns a plain directory into a git repository with a single commit
+func initEvalRepo(ctx context.Context, dir string) error {
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "-A"},
+		{"-c", "user.name=kratt eval", "-c", "user.email=kratt-eval@localhost", "commit", "--quiet", "--allow-empty", "-m", "Fixture"},
+	} {
+		if err := runGit(ctx, dir, args...); err != nil {
+			return fmt.Errorf("failed to initialize fixture repository: %w", err)
+		}
+	}
+	return nil
+}
+
+// runGit runs a git command in dir
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// copyDir recursively copies the directory src to dst
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// FormatEvalReportMarkdown renders the evaluation report as Markdown
+func FormatEvalReportMarkdown(report *EvalReport) string {
+	var b strings.Builder
+
+	b.WriteString("# Kratt Evaluation Report\n\n")
+	b.WriteString(fmt.Sprintf("- **Success rate:** %d/%d (%.1f%%)\n", report.Succeeded, len(report.Tasks), report.SuccessRate*100))
+	b.WriteString(fmt.Sprintf("- **Total time:** %s\n", report.Duration))
+	b.WriteString(fmt.Sprintf("- **Started:** %s\n\n", report.StartedAt.UTC().Format(time.RFC3339)))
+
+	b.WriteString("| Task | Result | Duration | Diff |\n")
+	b.WriteString("|---|---|---|---|\n")
+	for _, task := range report.Tasks {
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %d files, +%d/-%d |\n",
+			task.Name, passFail(task.Success), task.Duration,
+			task.Diff.Files, task.Diff.Additions, task.Diff.Deletions))
+	}
+
+	for _, task := range report.Tasks {
+		if task.Error == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\n## %s\n\n", task.Name))
+		b.WriteString("```\n")
+		b.WriteString(task.Error)
+		b.WriteString("\n```\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFileAgent is an agent that creates a file in the current directory
+type writeFileAgent struct {
+	path    string
+	content string
+}
+
+func (a *writeFileAgent) Name() string { return "write-file" }
+
+func (a *writeFileAgent) Run(ctx context.Context, runner CommandRunner, prompt string) (*AgentResult, error) {
+	return nil, os.WriteFile(a.path, []byte(a.content), 0644)
+}
+
+func TestLoadEvalTasks(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "b-task", "task.json"), `{"instruction": "Do B", "verify": ["true"]}`)
+	writeTestFile(t, filepath.Join(dir, "a-task", "task.json"), `{"name": "alpha", "instruction": "Do A", "verify": ["true"], "repo": "fixture.bundle"}`)
+	writeTestFile(t, filepath.Join(dir, "not-a-task", "README.md"), "ignored")
+
+	tasks, err := LoadEvalTasks(dir)
+	if err != nil {
+		t.Fatalf("LoadEvalTasks failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Name != "alpha" || tasks[0].Repo != "fixture.bundle" {
+		t.Errorf("Unexpected first task: %+v", tasks[0])
+	}
+	if tasks[1].Name != "b-task" || tasks[1].Repo != "repo" {
+		t.Errorf("Unexpected second task: %+v", tasks[1])
+	}
+
+	writeTestFile(t, filepath.Join(dir, "c-task", "task.json"), `{"instruction": "Do C"}`)
+	if _, err := LoadEvalTasks(dir); err == nil {
+		t.Error("Expected error for task without verification command")
+	}
+}
+
+func TestEvaluator(t *testing.T) {
+	requireGit(t)
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "greeting", "task.json"), `{"instruction": "Add a greeting", "verify": ["test", "-f", "hello.txt"]}`)
+	writeTestFile(t, filepath.Join(dir, "greeting", "repo", "README.md"), "# Fixture\n")
+	writeTestFile(t, filepath.Join(dir, "farewell", "task.json"), `{"instruction": "Add a farewell", "verify": ["test", "-f", "goodbye.txt"]}`)
+	writeTestFile(t, filepath.Join(dir, "farewell", "repo", "README.md"), "# Fixture\n")
+
+	tasks, err := LoadEvalTasks(dir)
+	if err != nil {
+		t.Fatalf("LoadEvalTasks failed: %v", err)
+	}
+
+	evaluator := &Evaluator{Worker: &Worker{
+		Instructions: "Implement the change.",
+		LintCommand:  []string{"true"},
+		TestCommand:  []string{"true"},
+		Deadline:     30 * time.Second,
+		Agent:        &writeFileAgent{path: "hello.txt", content: "hello\n"},
+	}}
+
+	report, err := evaluator.Evaluate(context.Background(), tasks)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+
+	if len(report.Tasks) != 2 || report.Succeeded != 1 || report.SuccessRate != 0.5 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	farewell, greeting := report.Tasks[0], report.Tasks[1]
+	if farewell.Success || !strings.Contains(farewell.Error, "verification failed") {
+		t.Errorf("Expected farewell task to fail verification, got %+v", farewell)
+	}
+	if !greeting.Success || greeting.Diff.Files != 1 || greeting.Diff.Additions != 1 {
+		t.Errorf("Expected greeting task to succeed with a one-line diff, got %+v", greeting)
+	}
+
+	markdown := FormatEvalReportMarkdown(report)
+	if !strings.Contains(markdown, "1/2 (50.0%)") || !strings.Contains(markdown, "| greeting | ✅ |") {
+		t.Errorf("Unexpected Markdown report:\n%s", markdown)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,8 +3,10 @@ package worker
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 // CommandRunner interface encapsulates command execution
@@ -14,14 +16,41 @@ type CommandRunner interface {
 
 	// RunWithOutput executes a command and returns interleaved stdout/stderr output
 	RunWithOutput(ctx context.Context, command string, args ...string) (output []byte, err error)
+
+	// RunWithStdinOutput executes a command with the given stdin input and returns its stdout
+	RunWithStdinOutput(ctx context.Context, stdin string, command string, args ...string) (output []byte, err error)
+}
+
+// DefaultGracePeriod is how long a cancelled command gets between SIGTERM and SIGKILL
+const DefaultGracePeriod = 10 * time.Second
+
+// ExecRunner implements CommandRunner interface using os/exec.
+// Commands run in their own process group; on cancellation the whole group
+// receives SIGTERM and, after GracePeriod, SIGKILL.
+type ExecRunner struct {
+	GracePeriod time.Duration // Zero means DefaultGracePeriod
+	Env         []string      // Names of environment variables passed to commands; nil passes all
 }
 
-// ExecRunner implements CommandRunner interface using os/exec
-type ExecRunner struct{}
+// command creates a command that terminates its process group on cancellation;
+// stop must be called once the command has been waited for
+func (e *ExecRunner) command(ctx context.Context, command string, args ...string) (cmd *exec.Cmd, stop func()) {
+	grace := e.GracePeriod
+	if grace == 0 {
+		grace = DefaultGracePeriod
+	}
+
+	cmd = exec.CommandContext(ctx, command, args...)
+	if e.Env != nil {
+		cmd.Env = filterEnv(os.Environ(), e.Env)
+	}
+	return cmd, configureProcessGroup(cmd, grace)
+}
 
 // RunWithStdin executes a command with the given stdin input
 func (e *ExecRunner) RunWithStdin(ctx context.Context, stdin string, command string, args ...string) error {
-	cmd := exec.CommandContext(ctx, command, args...)
+	cmd, stop := e.command(ctx, command, args...)
+	defer stop()
 	cmd.Stdin = strings.NewReader(stdin)
 
 	if err := cmd.Run(); err != nil {
@@ -32,7 +61,8 @@ func (e *ExecRunner) RunWithStdin(ctx context.Context, stdin string, command str
 
 // RunWithOutput executes a command and returns interleaved stdout/stderr output
 func (e *ExecRunner) RunWithOutput(ctx context.Context, command string, args ...string) (output []byte, err error) {
-	cmd := exec.CommandContext(ctx, command, args...)
+	cmd, stop := e.command(ctx, command, args...)
+	defer stop()
 	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return output, fmt.Errorf("command %s %v failed: %w", command, args, err)
@@ -40,11 +70,24 @@ func (e *ExecRunner) RunWithOutput(ctx context.Context, command string, args ...
 	return output, nil
 }
 
+// RunWithStdinOutput executes a command with the given stdin input and returns its stdout
+func (e *ExecRunner) RunWithStdinOutput(ctx context.Context, stdin string, command string, args ...string) (output []byte, err error) {
+	cmd, stop := e.command(ctx, command, args...)
+	defer stop()
+	cmd.Stdin = strings.NewReader(stdin)
+	output, err = cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("command %s %v failed: %w", command, args, err)
+	}
+	return output, nil
+}
+
 // FakeCommandRunner implements CommandRunner interface for testing
 type FakeCommandRunner struct {
 	stdinInputs map[string]string // command -> stdin input (for verification)
 	responses   map[string][]byte // command -> output response
 	errors      map[string]error  // command -> error to return
+	commands    []string          // executed commands in order
 }
 
 // NewFakeCommandRunner creates a new FakeCommandRunner instance
@@ -68,6 +111,7 @@ func (f *FakeCommandRunner) SetResponse(commandPattern string, output []byte, er
 func (f *FakeCommandRunner) RunWithStdin(ctx context.Context, stdin string, command string, args ...string) error {
 	cmdKey := fmt.Sprintf("%s %s", command, strings.Join(args, " "))
 	f.stdinInputs[cmdKey] = stdin
+	f.commands = append(f.commands, cmdKey)
 
 	if err, exists := f.errors[cmdKey]; exists {
 		return err
@@ -78,6 +122,7 @@ func (f *FakeCommandRunner) RunWithStdin(ctx context.Context, stdin string, comm
 // RunWithOutput returns configured output and error
 func (f *FakeCommandRunner) RunWithOutput(ctx context.Context, command string, args ...string) (output []byte, err error) {
 	cmdKey := fmt.Sprintf("%s %s", command, strings.Join(args, " "))
+	f.commands = append(f.commands, cmdKey)
 
 	if output, exists := f.responses[cmdKey]; exists {
 		if err, hasErr := f.errors[cmdKey]; hasErr {
@@ -90,6 +135,18 @@ func (f *FakeCommandRunner) RunWithOutput(ctx context.Context, command string, a
 	return []byte("fake output"), nil
 }
 
+// RunWithStdinOutput records stdin input and returns configured output and error
+func (f *FakeCommandRunner) RunWithStdinOutput(ctx context.Context, stdin string, command string, args ...string) (output []byte, err error) {
+	cmdKey := fmt.Sprintf("%s %s", command, strings.Join(args, " "))
+	f.stdinInputs[cmdKey] = stdin
+	return f.RunWithOutput(ctx, command, args...)
+}
+
+// GetCommands returns all executed commands in order (for testing)
+func (f *FakeCommandRunner) GetCommands() []string {
+	return f.commands
+}
+
 // GetStdinInput returns recorded stdin input for verification (for testing)
 func (f *FakeCommandRunner) GetStdinInput(command string) string {
 	return f.stdinInputs[command]
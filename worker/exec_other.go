@@ -0,0 +1,15 @@
+//go:build !unix
+
+package worker
+
+import (
+	"os/exec"
+	"time"
+)
+
+// configureProcessGroup only limits how long to wait for output after
+// cancellation; process groups are not supported on this platform
+func configureProcessGroup(cmd *exec.Cmd, grace time.Duration) (stop func()) {
+	cmd.WaitDelay = grace
+	return func() {}
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package worker
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// configureProcessGroup runs the command in its own process group and, on
+// cancellation, sends SIGTERM to the whole group followed by SIGKILL once
+// the grace period has passed. The returned function must be called once
+// Wait returns; it stops a pending SIGKILL so it cannot hit a reused group ID.
+func configureProcessGroup(cmd *exec.Cmd, grace time.Duration) (stop func()) {
+	var mu sync.Mutex
+	var kill *time.Timer
+	waited := false
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !waited {
+			kill = time.AfterFunc(grace, func() {
+				syscall.Kill(-pgid, syscall.SIGKILL)
+			})
+		}
+		return nil
+	}
+	// Wait for the group to exit after SIGKILL before giving up on output pipes
+	// held open by orphaned descendants
+	cmd.WaitDelay = grace + time.Second
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		waited = true
+		if kill != nil {
+			kill.Stop()
+		}
+	}
+}
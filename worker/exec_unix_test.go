@@ -0,0 +1,59 @@
+//go:build unix
+
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecRunnerKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	runner := &ExecRunner{GracePeriod: 100 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The child ignores SIGTERM, so only the SIGKILL after the grace period stops it
+	script := `trap "" TERM; sleep 30 & echo $! > ` + pidFile + `; wait`
+	started := time.Now()
+	_, err := runner.RunWithOutput(ctx, "sh", "-c", script)
+	if err == nil {
+		t.Fatal("Expected command to be cancelled")
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("Expected command to stop shortly after the deadline, took %s", elapsed)
+	}
+
+	content, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("Failed to read child pid: %v", err)
+	}
+	pid := strings.TrimSpace(string(content))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for processRunning(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected grandchild process %s to be killed", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// processRunning reports whether the process exists and is not a zombie
+func processRunning(pid string) bool {
+	if _, err := strconv.Atoi(pid); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", pid, "stat"))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) > 2 && fields[2] != "Z"
+}
@@ -1,75 +1,202 @@
 package worker
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // LocalGit interface encapsulates git worktree operations
 type LocalGit interface {
 	// CheckWorktreeExists checks if a worktree exists for the given branch
-	CheckWorktreeExists(branch string) (bool, error)
+	CheckWorktreeExists(ctx context.Context, branch string) (bool, error)
 
 	// CreateWorktree creates a new worktree for the given branch at the specified path
-	CreateWorktree(branch, path string) error
+	CreateWorktree(ctx context.Context, branch, path string) error
 
 	// ChangeDirectory changes to the specified worktree directory
-	ChangeDirectory(path string) error
+	ChangeDirectory(ctx context.Context, path string) error
 
 	// CommitAndPush commits all changes and pushes to the remote branch
-	CommitAndPush(message string) error
+	CommitAndPush(ctx context.Context, message string) error
 
 	// GetWorktreePath returns the path to the worktree for the given branch
-	GetWorktreePath(branch string) (string, error)
+	GetWorktreePath(ctx context.Context, branch string) (string, error)
 
 	// Repository detection methods (added for CLI support)
 	// IsGitRepository checks if the current directory is a git repository
-	IsGitRepository() (bool, error)
+	IsGitRepository(ctx context.Context) (bool, error)
 
 	// GetGitHubRepository extracts GitHub owner/repo from git remotes
-	GetGitHubRepository() (owner, repo string, err error)
+	GetGitHubRepository(ctx context.Context) (owner, repo string, err error)
 
 	// Start method support (added for Worker.Start)
-	// CreateBranch creates a new branch and switches to it
-	CreateBranch(branchName string) error
+	// CreateBranch creates a new branch at startPoint and checks it out in a new
+	// worktree at path, leaving the current checkout untouched
+	CreateBranch(ctx context.Context, branchName, startPoint, path string) error
 
 	// WriteFile writes content to a file at the specified path
-	WriteFile(path, content string) error
+	WriteFile(ctx context.Context, path, content string) error
 
 	// PushBranchUpstream pushes a new branch upstream with git push -u origin
-	PushBranchUpstream(branchName string) error
+	PushBranchUpstream(ctx context.Context, branchName string) error
 
 	// BranchExists checks if a branch exists
-	BranchExists(branchName string) (bool, error)
+	BranchExists(ctx context.Context, branchName string) (bool, error)
+
+	// Compare mode support (added for Worker.ComparePR)
+	// HeadSHA returns the commit SHA checked out in the current directory
+	HeadSHA(ctx context.Context) (string, error)
+
+	// CreateWorktreeAt creates or resets branch to startPoint and checks it out in a new worktree at path
+	CreateWorktreeAt(ctx context.Context, branch, path, startPoint string) error
+
+	// RemoveWorktree removes the worktree at the specified path
+	RemoveWorktree(ctx context.Context, path string) error
+
+	// StageAll stages all changes in the current directory, including untracked files
+	StageAll(ctx context.Context) error
+
+	// DiffStat summarizes the staged changes relative to the given base commit
+	DiffStat(ctx context.Context, base string) (DiffStat, error)
+
+	// Policy support (added for Worker.Policy)
+	// StagedDiff returns the unified diff of the staged changes relative to the given base commit
+	StagedDiff(ctx context.Context, base string) (string, error)
+
+	// Approval support (added for Worker.RequireApproval)
+	// Commit commits all changes locally and reports whether a commit was created
+	Commit(ctx context.Context, message string) (bool, error)
+
+	// Push pushes the current branch to origin
+	Push(ctx context.Context) error
+
+	// ResetHard resets the current branch and worktree to the given commit
+	ResetHard(ctx context.Context, ref string) error
+
+	// Plan mode support (added for Worker.PlanPR)
+	// ReadFile reads the content of a file at the specified path
+	ReadFile(ctx context.Context, path string) (string, error)
+
+	// Base branch support (added for Worker.Start)
+	// DefaultBranch returns the default branch of the origin remote, e.g. "main"
+	DefaultBranch(ctx context.Context) (string, error)
+
+	// FetchBranch updates origin/<branch> from the origin remote
+	FetchBranch(ctx context.Context, branch string) error
+
+	// CurrentDirectory returns the current working directory
+	CurrentDirectory(ctx context.Context) (string, error)
+
+	// Worktree management support (added for kratt worktree)
+	// ListWorktrees returns all worktrees of the repository, the main worktree first
+	ListWorktrees(ctx context.Context) ([]Worktree, error)
+
+	// Remote repository support (added for Worker.ProcessRemotePR)
+	// SyncMirror clones url as a bare repository at path if it does not exist
+	// yet and fetches all branches of origin into it
+	SyncMirror(ctx context.Context, url, path string) error
+
+	// Dirty worktree support (added for Worker.DirtyWorktree)
+	// Status describes the current worktree relative to origin/<branch>
+	Status(ctx context.Context, branch string) (WorktreeStatus, error)
+
+	// Stash stashes uncommitted changes, including untracked files
+	Stash(ctx context.Context, message string) error
+
+	// AbortOperation aborts an unfinished rebase, am, merge, cherry-pick or revert
+	AbortOperation(ctx context.Context, operation string) error
+
+	// CheckoutBranch checks out an existing branch in the current worktree
+	CheckoutBranch(ctx context.Context, branch string) error
+
+	// Clean removes untracked files and directories
+	Clean(ctx context.Context) error
+
+	// Agent commit support (added for Worker.AgentCommits)
+	// CommitsSince returns the commits reachable from HEAD but not from base, oldest first
+	CommitsSince(ctx context.Context, base string) ([]CommitInfo, error)
+
+	// ResetSoft moves the current branch to the given commit, keeping all changes staged
+	ResetSoft(ctx context.Context, ref string) error
+
+	// Revert support (added for Worker.RevertRun)
+	// Revert stages the changes undoing the commits after base up to commit, without committing
+	Revert(ctx context.Context, base, commit string) error
+
+	// PushWithLease force-pushes the current branch, provided origin's branch is still at expected
+	PushWithLease(ctx context.Context, expected string) error
+}
+
+// Worktree is a working tree of the repository
+type Worktree struct {
+	Path   string
+	Branch string // Empty for a detached HEAD
+	Head   string
+	Main   bool
+}
+
+// CommitInfo describes a commit in the history of a branch
+type CommitInfo struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	Message string `json:"message,omitempty"`
+}
+
+// DiffStat summarizes the size of a diff
+type DiffStat struct {
+	Files     int
+	Additions int
+	Deletions int
+}
+
+// Lines returns the total number of changed lines
+func (d DiffStat) Lines() int {
+	return d.Additions + d.Deletions
 }
 
 // GitRunner implements LocalGit interface using git commands
-type GitRunner struct{}
+type GitRunner struct {
+	WorktreeRoot string         // Directory for new worktrees; empty means next to the main worktree
+	Commits      CommitSettings // Identity, signing and sign-off of new commits
+}
 
-// CheckWorktreeExists checks if a worktree exists for the given branch
-func (g *GitRunner) CheckWorktreeExists(branch string) (bool, error) {
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
+// CheckWorktreeExists checks if a worktree has exactly the given branch checked out
+func (g *GitRunner) CheckWorktreeExists(ctx context.Context, branch string) (bool, error) {
+	worktrees, err := g.ListWorktrees(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to list worktrees: %w", err)
+		return false, err
 	}
-
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "branch ") && strings.Contains(line, branch) {
+	for _, worktree := range worktrees {
+		if worktree.Branch == branch {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+// ListWorktrees parses git worktree list --porcelain; the main worktree comes first
+func (g *GitRunner) ListWorktrees(ctx context.Context) ([]Worktree, error) {
+	cmd := exec.CommandContext(ctx, "git", "worktree", "list", "--porcelain")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	return parseWorktreeList(string(output)), nil
+}
+
 // CreateWorktree creates a new worktree for the given branch at the specified path
-func (g *GitRunner) CreateWorktree(branch, path string) error {
-	cmd := exec.Command("git", "worktree", "add", path, branch)
+func (g *GitRunner) CreateWorktree(ctx context.Context, branch, path string) error {
+	cmd := exec.CommandContext(ctx, "git", "worktree", "add", path, branch)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to create worktree for branch %s at %s: %w", branch, path, err)
 	}
@@ -77,7 +204,7 @@ func (g *GitRunner) CreateWorktree(branch, path string) error {
 }
 
 // ChangeDirectory changes to the specified worktree directory
-func (g *GitRunner) ChangeDirectory(path string) error {
+func (g *GitRunner) ChangeDirectory(ctx context.Context, path string) error {
 	if err := os.Chdir(path); err != nil {
 		return fmt.Errorf("failed to change directory to %s: %w", path, err)
 	}
@@ -85,33 +212,50 @@ func (g *GitRunner) ChangeDirectory(path string) error {
 }
 
 // CommitAndPush commits all changes and pushes to the remote branch
-func (g *GitRunner) CommitAndPush(message string) error {
+func (g *GitRunner) CommitAndPush(ctx context.Context, message string) error {
+	committed, err := g.Commit(ctx, message)
+	if err != nil || !committed {
+		return err
+	}
+	return g.Push(ctx)
+}
+
+// Commit commits all changes locally and reports whether a commit was created
+func (g *GitRunner) Commit(ctx context.Context, message string) (bool, error) {
 	// Add all changes
-	addCmd := exec.Command("git", "add", ".")
+	addCmd := exec.CommandContext(ctx, "git", "add", ".")
 	if err := addCmd.Run(); err != nil {
-		return fmt.Errorf("failed to add changes: %w", err)
+		return false, fmt.Errorf("failed to add changes: %w", err)
 	}
 
 	// Check if there are any changes to commit
-	statusCmd := exec.Command("git", "status", "--porcelain")
+	statusCmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
 	statusOutput, err := statusCmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to check git status: %w", err)
+		return false, fmt.Errorf("failed to check git status: %w", err)
 	}
 
-	// If no changes, skip commit and push
+	// If no changes, skip commit
 	if len(strings.TrimSpace(string(statusOutput))) == 0 {
-		return nil
+		return false, nil
 	}
 
 	// Commit changes
-	commitCmd := exec.Command("git", "commit", "-m", message)
-	if err := commitCmd.Run(); err != nil {
-		return fmt.Errorf("failed to commit changes: %w", err)
+	commitCmd := exec.CommandContext(ctx, "git", g.Commits.commitArgs(message)...)
+	commitCmd.Env = append(os.Environ(), g.Commits.commitEnv()...)
+	if output, err := commitCmd.CombinedOutput(); err != nil {
+		if len(output) > 0 {
+			return false, fmt.Errorf("failed to commit changes: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return false, fmt.Errorf("failed to commit changes: %w", err)
 	}
+	return true, nil
+}
 
+// Push pushes the current branch to origin
+func (g *GitRunner) Push(ctx context.Context) error {
 	// Get current branch name
-	branchCmd := exec.Command("git", "branch", "--show-current")
+	branchCmd := exec.CommandContext(ctx, "git", "branch", "--show-current")
 	branchOutput, err := branchCmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to get current branch: %w", err)
@@ -119,7 +263,7 @@ func (g *GitRunner) CommitAndPush(message string) error {
 	branchName := strings.TrimSpace(string(branchOutput))
 
 	// Push changes with upstream
-	pushCmd := exec.Command("git", "push", "-u", "origin", branchName)
+	pushCmd := exec.CommandContext(ctx, "git", "push", "-u", "origin", branchName)
 	if err := pushCmd.Run(); err != nil {
 		return fmt.Errorf("failed to push changes: %w", err)
 	}
@@ -127,26 +271,87 @@ func (g *GitRunner) CommitAndPush(message string) error {
 	return nil
 }
 
-// GetWorktreePath returns the path to the worktree for the given branch
-func (g *GitRunner) GetWorktreePath(branch string) (string, error) {
-	// Get the current repository root
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+// ResetHard resets the current branch and worktree to the given commit
+func (g *GitRunner) ResetHard(ctx context.Context, ref string) error {
+	cmd := exec.CommandContext(ctx, "git", "reset", "--hard", ref)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", ref, err)
+	}
+	return nil
+}
+
+// GetWorktreePath returns the path of the worktree that has the branch
+// checked out, or <root>/<repo>-<branch> for a new one, where root defaults to
+// the directory containing the main worktree and slashes in the branch name
+// are replaced so that worktrees are never nested
+func (g *GitRunner) GetWorktreePath(ctx context.Context, branch string) (string, error) {
+	worktrees, err := g.ListWorktrees(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get repository root: %w", err)
+		return "", err
+	}
+	return worktreePath(worktrees, g.WorktreeRoot, branch)
+}
+
+// worktreePath returns the path of the worktree that has the branch checked
+// out, or the path of a new worktree for it below root. Below a shared root,
+// the name includes the repository's parent directory, the owner for
+// mirrors, so that a/foo and b/foo do not share worktrees.
+func worktreePath(worktrees []Worktree, root, branch string) (string, error) {
+	if len(worktrees) == 0 {
+		return "", fmt.Errorf("failed to find the main worktree")
+	}
+	for _, worktree := range worktrees {
+		if worktree.Branch == branch {
+			return worktree.Path, nil
+		}
+	}
+
+	mainPath := worktrees[0].Path
+	repoName := strings.TrimSuffix(filepath.Base(mainPath), ".git")
+	if root == "" {
+		root = filepath.Dir(mainPath)
+	} else {
+		repoName = filepath.Base(filepath.Dir(mainPath)) + "-" + repoName
 	}
+	return filepath.Join(root, worktreeDirName(repoName, branch)), nil
+}
 
-	repoRoot := strings.TrimSpace(string(output))
-	// Create worktree path as ../repo-branch
-	repoName := filepath.Base(repoRoot)
-	worktreePath := filepath.Join(filepath.Dir(repoRoot), fmt.Sprintf("%s-%s", repoName, branch))
+// worktreeDirName returns the directory name of a branch's worktree. Slashes
+// are replaced by "-", and a branch name containing "/" or "-" gets a short
+// hash of itself appended, so that feature/x and feature-x do not share a
+// directory.
+func worktreeDirName(repoName, branch string) string {
+	if !strings.ContainsAny(branch, "/-") {
+		return repoName + "-" + branch
+	}
+	sum := sha256.Sum256([]byte(branch))
+	return repoName + "-" + strings.ReplaceAll(branch, "/", "-") + "-" + hex.EncodeToString(sum[:4])
+}
 
-	return worktreePath, nil
+// parseWorktreeList parses the output of git worktree list --porcelain
+func parseWorktreeList(output string) []Worktree {
+	var worktrees []Worktree
+	for _, line := range strings.Split(output, "\n") {
+		if path, ok := strings.CutPrefix(line, "worktree "); ok {
+			worktrees = append(worktrees, Worktree{Path: path, Main: len(worktrees) == 0})
+			continue
+		}
+		if len(worktrees) == 0 {
+			continue
+		}
+		current := &worktrees[len(worktrees)-1]
+		if head, ok := strings.CutPrefix(line, "HEAD "); ok {
+			current.Head = head
+		} else if ref, ok := strings.CutPrefix(line, "branch "); ok {
+			current.Branch = strings.TrimPrefix(ref, "refs/heads/")
+		}
+	}
+	return worktrees
 }
 
 // IsGitRepository checks if the current directory is a git repository
-func (g *GitRunner) IsGitRepository() (bool, error) {
-	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+func (g *GitRunner) IsGitRepository(ctx context.Context) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--is-inside-work-tree")
 	err := cmd.Run()
 	if err != nil {
 		// If git command fails, we're not in a git repository
@@ -156,15 +361,18 @@ func (g *GitRunner) IsGitRepository() (bool, error) {
 }
 
 // GetGitHubRepository extracts GitHub owner/repo from git remotes
-func (g *GitRunner) GetGitHubRepository() (owner, repo string, err error) {
-	cmd := exec.Command("git", "remote", "get-url", "origin")
+func (g *GitRunner) GetGitHubRepository(ctx context.Context) (owner, repo string, err error) {
+	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get remote origin URL: %w", err)
 	}
 
-	remoteURL := strings.TrimSpace(string(output))
+	return parseGitHubRemoteURL(strings.TrimSpace(string(output)))
+}
 
+// parseGitHubRemoteURL extracts owner and repo from a GitHub SSH or HTTPS remote URL
+func parseGitHubRemoteURL(remoteURL string) (owner, repo string, err error) {
 	// Handle SSH format: git@github.com:owner/repo.git
 	if strings.HasPrefix(remoteURL, "git@github.com:") {
 		path := strings.TrimPrefix(remoteURL, "git@github.com:")
@@ -200,17 +408,50 @@ func parseGitHubPath(path string) (owner, repo string, err error) {
 	return parts[0], parts[1], nil
 }
 
-// CreateBranch creates a new branch and switches to it
-func (g *GitRunner) CreateBranch(branchName string) error {
-	cmd := exec.Command("git", "checkout", "-b", branchName)
+// CreateBranch creates a new branch at startPoint and checks it out in a new worktree at path
+func (g *GitRunner) CreateBranch(ctx context.Context, branchName, startPoint, path string) error {
+	cmd := exec.CommandContext(ctx, "git", "worktree", "add", "-b", branchName, path, startPoint)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create branch %s from %s at %s: %w", branchName, startPoint, path, err)
+	}
+	return nil
+}
+
+// DefaultBranch returns the branch origin/HEAD points to, asking the remote if it is not known locally
+func (g *GitRunner) DefaultBranch(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output()
+	if err != nil {
+		if err := exec.CommandContext(ctx, "git", "remote", "set-head", "origin", "--auto").Run(); err != nil {
+			return "", fmt.Errorf("failed to determine default branch of origin: %w", err)
+		}
+		output, err = exec.CommandContext(ctx, "git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine default branch of origin: %w", err)
+		}
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"), nil
+}
+
+// FetchBranch updates origin/<branch> from the origin remote
+func (g *GitRunner) FetchBranch(ctx context.Context, branch string) error {
+	cmd := exec.CommandContext(ctx, "git", "fetch", "origin", branch)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create and switch to branch %s: %w", branchName, err)
+		return fmt.Errorf("failed to fetch %s from origin: %w", branch, err)
 	}
 	return nil
 }
 
+// CurrentDirectory returns the current working directory
+func (g *GitRunner) CurrentDirectory(ctx context.Context) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return dir, nil
+}
+
 // WriteFile writes content to a file at the specified path
-func (g *GitRunner) WriteFile(path, content string) error {
+func (g *GitRunner) WriteFile(ctx context.Context, path, content string) error {
 	// Ensure the directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -223,9 +464,18 @@ func (g *GitRunner) WriteFile(path, content string) error {
 	return nil
 }
 
+// ReadFile reads the content of a file at the specified path
+func (g *GitRunner) ReadFile(ctx context.Context, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	return string(content), nil
+}
+
 // PushBranchUpstream pushes a new branch upstream with git push -u origin
-func (g *GitRunner) PushBranchUpstream(branchName string) error {
-	cmd := exec.Command("git", "push", "-u", "origin", branchName)
+func (g *GitRunner) PushBranchUpstream(ctx context.Context, branchName string) error {
+	cmd := exec.CommandContext(ctx, "git", "push", "-u", "origin", branchName)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to push branch %s upstream: %w", branchName, err)
 	}
@@ -233,8 +483,8 @@ func (g *GitRunner) PushBranchUpstream(branchName string) error {
 }
 
 // BranchExists checks if a branch exists
-func (g *GitRunner) BranchExists(branchName string) (bool, error) {
-	cmd := exec.Command("git", "branch", "--list", branchName)
+func (g *GitRunner) BranchExists(ctx context.Context, branchName string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "branch", "--list", branchName)
 	output, err := cmd.Output()
 	if err != nil {
 		return false, fmt.Errorf("failed to list branches: %w", err)
@@ -244,6 +494,276 @@ func (g *GitRunner) BranchExists(branchName string) (bool, error) {
 	return len(strings.TrimSpace(string(output))) > 0, nil
 }
 
+// HeadSHA returns the commit SHA checked out in the current directory
+func (g *GitRunner) HeadSHA(ctx context.Context) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CreateWorktreeAt creates or resets branch to startPoint and checks it out in a new worktree at path
+func (g *GitRunner) CreateWorktreeAt(ctx context.Context, branch, path, startPoint string) error {
+	cmd := exec.CommandContext(ctx, "git", "worktree", "add", "-B", branch, path, startPoint)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create worktree for branch %s at %s from %s: %w", branch, path, startPoint, err)
+	}
+	return nil
+}
+
+// RemoveWorktree removes the worktree at the specified path
+func (g *GitRunner) RemoveWorktree(ctx context.Context, path string) error {
+	cmd := exec.CommandContext(ctx, "git", "worktree", "remove", "--force", path)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove worktree at %s: %w", path, err)
+	}
+	return nil
+}
+
+// SyncMirror clones url as a bare repository at path if needed and fetches
+// origin's branches as remote-tracking branches, so that worktrees of the
+// mirror can be reset to origin/<branch> and pushed like those of a checkout
+func (g *GitRunner) SyncMirror(ctx context.Context, url, path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create mirror directory: %w", err)
+		}
+		if err := exec.CommandContext(ctx, "git", "clone", "--bare", url, path).Run(); err != nil {
+			return fmt.Errorf("failed to clone %s: %w", url, err)
+		}
+		// A bare clone has no fetch refspec; track origin's branches like a regular clone
+		cmd := exec.CommandContext(ctx, "git", "-C", path, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to configure mirror %s: %w", path, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to check mirror %s: %w", path, err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "fetch", "--prune", "origin")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to fetch %s into mirror: %w", url, err)
+	}
+	return nil
+}
+
+// Status describes the current worktree relative to origin/<branch>
+func (g *GitRunner) Status(ctx context.Context, branch string) (WorktreeStatus, error) {
+	var status WorktreeStatus
+	output, err := exec.CommandContext(ctx, "git", "status", "--porcelain").Output()
+	if err != nil {
+		return status, fmt.Errorf("failed to get status: %w", err)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line != "" {
+			status.Changes = append(status.Changes, line)
+		}
+	}
+
+	// symbolic-ref fails for a detached HEAD
+	if output, err := exec.CommandContext(ctx, "git", "symbolic-ref", "--quiet", "--short", "HEAD").Output(); err == nil {
+		status.Branch = strings.TrimSpace(string(output))
+	}
+
+	output, err = exec.CommandContext(ctx, "git", "rev-parse", "--git-dir").Output()
+	if err != nil {
+		return status, fmt.Errorf("failed to find git directory: %w", err)
+	}
+	status.Operation = operationInProgress(strings.TrimSpace(string(output)))
+
+	output, err = exec.CommandContext(ctx, "git", "rev-list", "--left-right", "--count", "HEAD...origin/"+branch).Output()
+	if err != nil {
+		return status, fmt.Errorf("failed to compare with origin/%s: %w", branch, err)
+	}
+	if _, err := fmt.Sscanf(string(output), "%d %d", &status.Ahead, &status.Behind); err != nil {
+		return status, fmt.Errorf("failed to parse commit counts %q: %w", output, err)
+	}
+	return status, nil
+}
+
+// operationInProgress returns the operation whose state files are in gitDir, if any
+func operationInProgress(gitDir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(gitDir, name))
+		return err == nil
+	}
+	switch {
+	case exists("rebase-merge"):
+		return "rebase"
+	case exists("rebase-apply/applying"):
+		return "am"
+	case exists("rebase-apply"):
+		return "rebase"
+	case exists("MERGE_HEAD"):
+		return "merge"
+	case exists("CHERRY_PICK_HEAD"):
+		return "cherry-pick"
+	case exists("REVERT_HEAD"):
+		return "revert"
+	}
+	return ""
+}
+
+// Stash stashes uncommitted changes, including untracked files
+func (g *GitRunner) Stash(ctx context.Context, message string) error {
+	cmd := exec.CommandContext(ctx, "git", "stash", "push", "--include-untracked", "-m", message)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to stash changes: %w", err)
+	}
+	return nil
+}
+
+// AbortOperation aborts an unfinished rebase, am, merge, cherry-pick or revert
+func (g *GitRunner) AbortOperation(ctx context.Context, operation string) error {
+	cmd := exec.CommandContext(ctx, "git", operation, "--abort")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to abort %s: %w", operation, err)
+	}
+	return nil
+}
+
+// CheckoutBranch checks out an existing branch in the current worktree
+func (g *GitRunner) CheckoutBranch(ctx context.Context, branch string) error {
+	cmd := exec.CommandContext(ctx, "git", "checkout", branch)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", branch, err)
+	}
+	return nil
+}
+
+// Clean removes untracked files and directories
+func (g *GitRunner) Clean(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "git", "clean", "-fd")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove untracked files: %w", err)
+	}
+	return nil
+}
+
+// CommitsSince returns the commits reachable from HEAD but not from base, oldest first
+func (g *GitRunner) CommitsSince(ctx context.Context, base string) ([]CommitInfo, error) {
+	cmd := exec.CommandContext(ctx, "git", "log", "--reverse", "--format=%H%x00%B%x1e", base+"..HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits since %s: %w", base, err)
+	}
+
+	commits := []CommitInfo{}
+	for _, entry := range strings.Split(string(output), "\x1e") {
+		sha, message, ok := strings.Cut(strings.TrimLeft(entry, "\n"), "\x00")
+		if !ok {
+			continue
+		}
+		commits = append(commits, newCommitInfo(sha, message))
+	}
+	return commits, nil
+}
+
+// newCommitInfo describes a commit by its SHA and full message
+func newCommitInfo(sha, message string) CommitInfo {
+	message = strings.TrimSpace(message)
+	subject, _, _ := strings.Cut(message, "\n")
+	return CommitInfo{SHA: sha, Subject: subject, Message: message}
+}
+
+// ResetSoft moves the current branch to the given commit, keeping all changes staged
+func (g *GitRunner) ResetSoft(ctx context.Context, ref string) error {
+	cmd := exec.CommandContext(ctx, "git", "reset", "--soft", ref)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", ref, err)
+	}
+	return nil
+}
+
+// Revert stages the changes undoing the commits after base up to commit,
+// without committing. The diff of the whole range is applied in reverse, so
+// that ranges containing merge commits can be reverted; a conflicting revert
+// is discarded.
+func (g *GitRunner) Revert(ctx context.Context, base, commit string) error {
+	diffCmd := exec.CommandContext(ctx, "git", "diff", "--binary", base, commit)
+	patch, err := diffCmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to diff %s..%s: %w", shortSHA(base), shortSHA(commit), err)
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+
+	applyCmd := exec.CommandContext(ctx, "git", "apply", "--reverse", "--index", "--3way")
+	applyCmd.Stdin = bytes.NewReader(patch)
+	if output, err := applyCmd.CombinedOutput(); err != nil {
+		exec.CommandContext(ctx, "git", "reset", "--hard", "--quiet", "HEAD").Run()
+		return fmt.Errorf("failed to revert %s..%s: %w: %s", shortSHA(base), shortSHA(commit), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// PushWithLease force-pushes the current branch, provided origin's branch is still at expected
+func (g *GitRunner) PushWithLease(ctx context.Context, expected string) error {
+	branchCmd := exec.CommandContext(ctx, "git", "branch", "--show-current")
+	branchOutput, err := branchCmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+	branchName := strings.TrimSpace(string(branchOutput))
+
+	pushCmd := exec.CommandContext(ctx, "git", "push", "--force-with-lease="+branchName+":"+expected, "origin", branchName)
+	if output, err := pushCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to force-push %s: %w: %s", branchName, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// StageAll stages all changes in the current directory, including untracked files
+func (g *GitRunner) StageAll(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "git", "add", "-A")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	return nil
+}
+
+// DiffStat summarizes the staged changes relative to the given base commit
+func (g *GitRunner) DiffStat(ctx context.Context, base string) (DiffStat, error) {
+	cmd := exec.CommandContext(ctx, "git", "diff", "--cached", "--numstat", base)
+	output, err := cmd.Output()
+	if err != nil {
+		return DiffStat{}, fmt.Errorf("failed to get diff against %s: %w", base, err)
+	}
+	return parseNumstat(string(output)), nil
+}
+
+// StagedDiff returns the unified diff of the staged changes relative to the given base commit
+func (g *GitRunner) StagedDiff(ctx context.Context, base string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false", "diff", "--cached", "--no-color", "--no-ext-diff", "-M", base)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff against %s: %w", base, err)
+	}
+	return string(output), nil
+}
+
+// parseNumstat parses the output of git diff --numstat; binary files count as changed files without lines
+func parseNumstat(output string) DiffStat {
+	var stat DiffStat
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		stat.Files++
+		if additions, err := strconv.Atoi(fields[0]); err == nil {
+			stat.Additions += additions
+		}
+		if deletions, err := strconv.Atoi(fields[1]); err == nil {
+			stat.Deletions += deletions
+		}
+	}
+	return stat
+}
+
 // FakeLocalGit implements LocalGit interface for testing
 type FakeLocalGit struct {
 	worktrees       map[string]string // branch -> path mapping
@@ -253,8 +773,24 @@ type FakeLocalGit struct {
 	githubOwner     string
 	githubRepo      string
 	createdBranches []string          // track created branches
+	startPoints     map[string]string // branch -> start point passed to CreateBranch
+	defaultBranch   string
+	fetchedBranches []string          // branches passed to FetchBranch
 	writtenFiles    map[string]string // path -> content mapping
 	pushedBranches  []string          // track pushed branches
+	headSHA         string
+	diffStat        DiffStat
+	stagedDiff      string
+	pushes          int               // number of Push calls
+	resets          []string          // refs passed to ResetHard
+	mirrors         map[string]string // path -> url passed to SyncMirror
+	status          *WorktreeStatus   // returned by Status; nil means clean
+	stashes         []string          // messages passed to Stash
+	aborted         []string          // operations passed to AbortOperation
+	agentCommits    []CommitInfo      // returned by CommitsSince until ResetSoft
+	softResets      []string          // refs passed to ResetSoft
+	reverts         []string          // "base..commit" ranges passed to Revert
+	leases          []string          // expected commits passed to PushWithLease
 
 	// Error simulation flags
 	FailCreateBranch        bool
@@ -274,31 +810,45 @@ func NewFakeLocalGit() *FakeLocalGit {
 		githubOwner:     "owner",
 		githubRepo:      "repo",
 		createdBranches: []string{},
+		startPoints:     make(map[string]string),
+		defaultBranch:   "main",
 		writtenFiles:    make(map[string]string),
 		pushedBranches:  []string{},
+		headSHA:         "0000000000000000000000000000000000000000",
+		mirrors:         make(map[string]string),
 	}
 }
 
+// ListWorktrees returns the fake main worktree followed by all worktrees sorted by branch
+func (f *FakeLocalGit) ListWorktrees(ctx context.Context) ([]Worktree, error) {
+	worktrees := []Worktree{{Path: "/fake/repo", Main: true}}
+	for branch, path := range f.worktrees {
+		worktrees = append(worktrees, Worktree{Path: path, Branch: branch, Head: f.headSHA})
+	}
+	sort.Slice(worktrees[1:], func(i, j int) bool { return worktrees[i+1].Branch < worktrees[j+1].Branch })
+	return worktrees, nil
+}
+
 // CheckWorktreeExists checks if a worktree exists in the fake state
-func (f *FakeLocalGit) CheckWorktreeExists(branch string) (bool, error) {
+func (f *FakeLocalGit) CheckWorktreeExists(ctx context.Context, branch string) (bool, error) {
 	_, exists := f.worktrees[branch]
 	return exists, nil
 }
 
 // CreateWorktree adds a worktree to the fake state
-func (f *FakeLocalGit) CreateWorktree(branch, path string) error {
+func (f *FakeLocalGit) CreateWorktree(ctx context.Context, branch, path string) error {
 	f.worktrees[branch] = path
 	return nil
 }
 
 // ChangeDirectory updates the fake current directory
-func (f *FakeLocalGit) ChangeDirectory(path string) error {
+func (f *FakeLocalGit) ChangeDirectory(ctx context.Context, path string) error {
 	f.currentDir = path
 	return nil
 }
 
 // CommitAndPush records a commit in the fake state
-func (f *FakeLocalGit) CommitAndPush(message string) error {
+func (f *FakeLocalGit) CommitAndPush(ctx context.Context, message string) error {
 	if f.FailCommitAndPush {
 		return fmt.Errorf("fake commit and push failure")
 	}
@@ -307,7 +857,7 @@ func (f *FakeLocalGit) CommitAndPush(message string) error {
 }
 
 // GetWorktreePath returns the path for a branch or generates one
-func (f *FakeLocalGit) GetWorktreePath(branch string) (string, error) {
+func (f *FakeLocalGit) GetWorktreePath(ctx context.Context, branch string) (string, error) {
 	if path, exists := f.worktrees[branch]; exists {
 		return path, nil
 	}
@@ -325,12 +875,12 @@ func (f *FakeLocalGit) GetCurrentDir() string {
 }
 
 // IsGitRepository returns the configured git repository status (for testing)
-func (f *FakeLocalGit) IsGitRepository() (bool, error) {
+func (f *FakeLocalGit) IsGitRepository(ctx context.Context) (bool, error) {
 	return f.isGitRepo, nil
 }
 
 // GetGitHubRepository returns the configured GitHub owner/repo (for testing)
-func (f *FakeLocalGit) GetGitHubRepository() (owner, repo string, err error) {
+func (f *FakeLocalGit) GetGitHubRepository(ctx context.Context) (owner, repo string, err error) {
 	if f.FailGetGitHubRepository {
 		return "", "", fmt.Errorf("fake get github repository failure")
 	}
@@ -348,17 +898,45 @@ func (f *FakeLocalGit) SetGitHubRepository(owner, repo string) {
 	f.githubRepo = repo
 }
 
-// CreateBranch records a created branch in the fake state
-func (f *FakeLocalGit) CreateBranch(branchName string) error {
+// CreateBranch records a created branch and its worktree in the fake state
+func (f *FakeLocalGit) CreateBranch(ctx context.Context, branchName, startPoint, path string) error {
 	if f.FailCreateBranch {
 		return fmt.Errorf("fake create branch failure")
 	}
 	f.createdBranches = append(f.createdBranches, branchName)
+	f.startPoints[branchName] = startPoint
+	f.worktrees[branchName] = path
+	return nil
+}
+
+// DefaultBranch returns the configured default branch
+func (f *FakeLocalGit) DefaultBranch(ctx context.Context) (string, error) {
+	return f.defaultBranch, nil
+}
+
+// SetDefaultBranch sets the branch returned by DefaultBranch (for testing)
+func (f *FakeLocalGit) SetDefaultBranch(branch string) {
+	f.defaultBranch = branch
+}
+
+// FetchBranch records a fetched branch
+func (f *FakeLocalGit) FetchBranch(ctx context.Context, branch string) error {
+	f.fetchedBranches = append(f.fetchedBranches, branch)
 	return nil
 }
 
+// GetFetchedBranches returns all fetched branches (for testing)
+func (f *FakeLocalGit) GetFetchedBranches() []string {
+	return f.fetchedBranches
+}
+
+// CurrentDirectory returns the fake current directory
+func (f *FakeLocalGit) CurrentDirectory(ctx context.Context) (string, error) {
+	return f.currentDir, nil
+}
+
 // WriteFile stores file content in the fake state
-func (f *FakeLocalGit) WriteFile(path, content string) error {
+func (f *FakeLocalGit) WriteFile(ctx context.Context, path, content string) error {
 	if f.FailWriteFile {
 		return fmt.Errorf("fake write file failure")
 	}
@@ -366,8 +944,17 @@ func (f *FakeLocalGit) WriteFile(path, content string) error {
 	return nil
 }
 
+// ReadFile returns file content from the fake state
+func (f *FakeLocalGit) ReadFile(ctx context.Context, path string) (string, error) {
+	content, exists := f.writtenFiles[path]
+	if !exists {
+		return "", fmt.Errorf("fake file %s not found", path)
+	}
+	return content, nil
+}
+
 // PushBranchUpstream records a pushed branch in the fake state
-func (f *FakeLocalGit) PushBranchUpstream(branchName string) error {
+func (f *FakeLocalGit) PushBranchUpstream(ctx context.Context, branchName string) error {
 	if f.FailPushBranchUpstream {
 		return fmt.Errorf("fake push branch upstream failure")
 	}
@@ -375,6 +962,11 @@ func (f *FakeLocalGit) PushBranchUpstream(branchName string) error {
 	return nil
 }
 
+// GetStartPoint returns the start point a branch was created at (for testing)
+func (f *FakeLocalGit) GetStartPoint(branch string) string {
+	return f.startPoints[branch]
+}
+
 // GetCreatedBranches returns all created branches (for testing)
 func (f *FakeLocalGit) GetCreatedBranches() []string {
 	return f.createdBranches
@@ -391,7 +983,7 @@ func (f *FakeLocalGit) GetPushedBranches() []string {
 }
 
 // BranchExists checks if a branch exists in the fake state
-func (f *FakeLocalGit) BranchExists(branchName string) (bool, error) {
+func (f *FakeLocalGit) BranchExists(ctx context.Context, branchName string) (bool, error) {
 	for _, branch := range f.createdBranches {
 		if branch == branchName {
 			return true, nil
@@ -399,3 +991,213 @@ func (f *FakeLocalGit) BranchExists(branchName string) (bool, error) {
 	}
 	return false, nil
 }
+
+// HeadSHA returns the configured HEAD commit
+func (f *FakeLocalGit) HeadSHA(ctx context.Context) (string, error) {
+	return f.headSHA, nil
+}
+
+// SetHeadSHA sets the HEAD commit returned by HeadSHA (for testing)
+func (f *FakeLocalGit) SetHeadSHA(sha string) {
+	f.headSHA = sha
+}
+
+// CreateWorktreeAt adds a worktree to the fake state
+func (f *FakeLocalGit) CreateWorktreeAt(ctx context.Context, branch, path, startPoint string) error {
+	f.worktrees[branch] = path
+	return nil
+}
+
+// RemoveWorktree removes the worktree at path from the fake state
+func (f *FakeLocalGit) RemoveWorktree(ctx context.Context, path string) error {
+	for branch, p := range f.worktrees {
+		if p == path {
+			delete(f.worktrees, branch)
+		}
+	}
+	return nil
+}
+
+// StageAll does nothing in the fake
+func (f *FakeLocalGit) StageAll(ctx context.Context) error {
+	return nil
+}
+
+// DiffStat returns the configured diff statistics
+func (f *FakeLocalGit) DiffStat(ctx context.Context, base string) (DiffStat, error) {
+	return f.diffStat, nil
+}
+
+// SetDiffStat sets the diff statistics returned by DiffStat (for testing)
+func (f *FakeLocalGit) SetDiffStat(stat DiffStat) {
+	f.diffStat = stat
+}
+
+// StagedDiff returns the configured staged diff
+func (f *FakeLocalGit) StagedDiff(ctx context.Context, base string) (string, error) {
+	return f.stagedDiff, nil
+}
+
+// SetStagedDiff sets the diff returned by StagedDiff (for testing)
+func (f *FakeLocalGit) SetStagedDiff(diff string) {
+	f.stagedDiff = diff
+}
+
+// Commit records a local commit and moves the fake HEAD
+func (f *FakeLocalGit) Commit(ctx context.Context, message string) (bool, error) {
+	if f.FailCommitAndPush {
+		return false, fmt.Errorf("fake commit failure")
+	}
+	f.commits = append(f.commits, message)
+	f.headSHA = fmt.Sprintf("%040x", len(f.commits))
+	return true, nil
+}
+
+// Push records a push in the fake state
+func (f *FakeLocalGit) Push(ctx context.Context) error {
+	if f.FailCommitAndPush {
+		return fmt.Errorf("fake push failure")
+	}
+	f.pushes++
+	return nil
+}
+
+// ResetHard records the reset, moves the fake HEAD and discards the fake
+// status's changes; resetting to a remote branch also discards local commits
+func (f *FakeLocalGit) ResetHard(ctx context.Context, ref string) error {
+	f.resets = append(f.resets, ref)
+	f.headSHA = ref
+	if f.status != nil {
+		f.status.Changes = nil
+		if strings.HasPrefix(ref, "origin/") {
+			f.status.Ahead, f.status.Behind = 0, 0
+		}
+	}
+	return nil
+}
+
+// GetPushCount returns the number of Push calls (for testing)
+func (f *FakeLocalGit) GetPushCount() int {
+	return f.pushes
+}
+
+// CommitsSince returns the configured agent commits
+func (f *FakeLocalGit) CommitsSince(ctx context.Context, base string) ([]CommitInfo, error) {
+	return f.agentCommits, nil
+}
+
+// SetAgentCommits sets the commits returned by CommitsSince (for testing)
+func (f *FakeLocalGit) SetAgentCommits(commits []CommitInfo) {
+	f.agentCommits = commits
+}
+
+// ResetSoft records the reset and drops the agent commits
+func (f *FakeLocalGit) ResetSoft(ctx context.Context, ref string) error {
+	f.softResets = append(f.softResets, ref)
+	f.headSHA = ref
+	f.agentCommits = nil
+	return nil
+}
+
+// GetSoftResets returns the refs passed to ResetSoft (for testing)
+func (f *FakeLocalGit) GetSoftResets() []string {
+	return f.softResets
+}
+
+// Revert records the reverted range
+func (f *FakeLocalGit) Revert(ctx context.Context, base, commit string) error {
+	f.reverts = append(f.reverts, base+".."+commit)
+	return nil
+}
+
+// GetReverts returns the ranges passed to Revert (for testing)
+func (f *FakeLocalGit) GetReverts() []string {
+	return f.reverts
+}
+
+// PushWithLease records a forced push in the fake state
+func (f *FakeLocalGit) PushWithLease(ctx context.Context, expected string) error {
+	if f.FailCommitAndPush {
+		return fmt.Errorf("fake push failure")
+	}
+	f.leases = append(f.leases, expected)
+	return nil
+}
+
+// GetLeases returns the expected commits passed to PushWithLease (for testing)
+func (f *FakeLocalGit) GetLeases() []string {
+	return f.leases
+}
+
+// GetResets returns the refs passed to ResetHard (for testing)
+func (f *FakeLocalGit) GetResets() []string {
+	return f.resets
+}
+
+// SyncMirror records the mirror in the fake state
+func (f *FakeLocalGit) SyncMirror(ctx context.Context, url, path string) error {
+	f.mirrors[path] = url
+	return nil
+}
+
+// GetMirrors returns the synced mirrors as path -> url (for testing)
+func (f *FakeLocalGit) GetMirrors() map[string]string {
+	return f.mirrors
+}
+
+// Status returns the configured status, or a clean worktree on branch
+func (f *FakeLocalGit) Status(ctx context.Context, branch string) (WorktreeStatus, error) {
+	if f.status == nil {
+		return WorktreeStatus{Branch: branch}, nil
+	}
+	return *f.status, nil
+}
+
+// SetStatus sets the status returned by Status (for testing)
+func (f *FakeLocalGit) SetStatus(status WorktreeStatus) {
+	f.status = &status
+}
+
+// Stash records the stash and clears the fake status's changes
+func (f *FakeLocalGit) Stash(ctx context.Context, message string) error {
+	f.stashes = append(f.stashes, message)
+	if f.status != nil {
+		f.status.Changes = nil
+	}
+	return nil
+}
+
+// GetStashes returns the messages passed to Stash (for testing)
+func (f *FakeLocalGit) GetStashes() []string {
+	return f.stashes
+}
+
+// AbortOperation records the operation and clears it from the fake status
+func (f *FakeLocalGit) AbortOperation(ctx context.Context, operation string) error {
+	f.aborted = append(f.aborted, operation)
+	if f.status != nil {
+		f.status.Operation = ""
+	}
+	return nil
+}
+
+// GetAbortedOperations returns the operations passed to AbortOperation (for testing)
+func (f *FakeLocalGit) GetAbortedOperations() []string {
+	return f.aborted
+}
+
+// CheckoutBranch sets the fake status's branch
+func (f *FakeLocalGit) CheckoutBranch(ctx context.Context, branch string) error {
+	if f.status != nil {
+		f.status.Branch = branch
+	}
+	return nil
+}
+
+// Clean clears the fake status's changes
+func (f *FakeLocalGit) Clean(ctx context.Context) error {
+	if f.status != nil {
+		f.status.Changes = nil
+	}
+	return nil
+}
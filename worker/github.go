@@ -1,29 +1,82 @@
 package worker
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 // GitHub interface encapsulates GitHub operations
 type GitHub interface {
 	// GetPRInfo retrieves pull request information including comments
-	GetPRInfo(prNumber int) (string, error)
+	GetPRInfo(ctx context.Context, prNumber int) (string, error)
 
 	// PostComment posts a comment to the specified pull request
-	PostComment(prNumber int, body string) error
+	PostComment(ctx context.Context, prNumber int, body string) error
 
-	// CreatePR creates a new pull request with the given title and description
-	CreatePR(title, description string) error
+	// CreatePR creates a new pull request for the current branch with the given title and description
+	CreatePR(ctx context.Context, title, description string, options PROptions) (PullRequest, error)
+
+	// GetIssue retrieves issue information including comments
+	GetIssue(ctx context.Context, issueNumber int) (string, error)
+
+	// FindPR returns the number of the open pull request for a branch, or 0 if there is none
+	FindPR(ctx context.Context, branch string) (int, error)
+
+	// PRState returns the state of the most recent pull request for a branch
+	// (PRStateOpen, PRStateMerged or PRStateClosed), or "" if there is none
+	PRState(ctx context.Context, branch string) (string, error)
+
+	// CurrentUser returns the login of the authenticated GitHub user
+	CurrentUser(ctx context.Context) (string, error)
+}
+
+// Pull request states reported by PRState
+const (
+	PRStateOpen   = "OPEN"
+	PRStateMerged = "MERGED"
+	PRStateClosed = "CLOSED"
+)
+
+// PROptions are optional settings for a new pull request
+type PROptions struct {
+	Base      string // Base branch; empty means the repository's default branch
+	Draft     bool
+	Labels    []string
+	Reviewers []string
+	Assignees []string
+	Milestone string
+}
+
+// PullRequest identifies a created pull request
+type PullRequest struct {
+	Number int
+	URL    string
 }
 
+// prURLPattern extracts the number from a pull request URL
+var prURLPattern = regexp.MustCompile(`/pull/(\d+)\s*$`)
+
 // GitHubCLI implements GitHub interface using GitHub CLI
-type GitHubCLI struct{}
+type GitHubCLI struct {
+	Repo string // owner/repo to operate on; empty means the repository of the current directory
+}
+
+// command returns a gh command targeting Repo
+func (g *GitHubCLI) command(ctx context.Context, args ...string) *exec.Cmd {
+	if g.Repo != "" {
+		args = append(args, "--repo", g.Repo)
+	}
+	return exec.CommandContext(ctx, "gh", args...)
+}
 
 // GetPRInfo retrieves pull request information using gh CLI
-func (g *GitHubCLI) GetPRInfo(prNumber int) (string, error) {
-	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "title,body,headRefName,comments")
+func (g *GitHubCLI) GetPRInfo(ctx context.Context, prNumber int) (string, error) {
+	cmd := g.command(ctx, "pr", "view", strconv.Itoa(prNumber), "--json", "title,body,headRefName,comments,labels,additions,deletions")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get PR info for #%d: %w", prNumber, err)
@@ -32,8 +85,8 @@ func (g *GitHubCLI) GetPRInfo(prNumber int) (string, error) {
 }
 
 // PostComment posts a comment to the specified pull request using gh CLI
-func (g *GitHubCLI) PostComment(prNumber int, body string) error {
-	cmd := exec.Command("gh", "pr", "comment", strconv.Itoa(prNumber), "--body", body)
+func (g *GitHubCLI) PostComment(ctx context.Context, prNumber int, body string) error {
+	cmd := g.command(ctx, "pr", "comment", strconv.Itoa(prNumber), "--body", body)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to post comment to PR #%d: %w", prNumber, err)
 	}
@@ -41,19 +94,115 @@ func (g *GitHubCLI) PostComment(prNumber int, body string) error {
 }
 
 // CreatePR creates a new pull request using gh CLI
-func (g *GitHubCLI) CreatePR(title, description string) error {
-	cmd := exec.Command("gh", "pr", "create", "--title", title, "--body", description)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create PR with title '%s': %w", title, err)
+func (g *GitHubCLI) CreatePR(ctx context.Context, title, description string, options PROptions) (PullRequest, error) {
+	args := []string{"pr", "create", "--title", title, "--body", description}
+	if options.Base != "" {
+		args = append(args, "--base", options.Base)
 	}
-	return nil
+	if options.Draft {
+		args = append(args, "--draft")
+	}
+	for _, label := range options.Labels {
+		args = append(args, "--label", label)
+	}
+	for _, reviewer := range options.Reviewers {
+		args = append(args, "--reviewer", reviewer)
+	}
+	for _, assignee := range options.Assignees {
+		args = append(args, "--assignee", assignee)
+	}
+	if options.Milestone != "" {
+		args = append(args, "--milestone", options.Milestone)
+	}
+	cmd := g.command(ctx, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("failed to create PR with title '%s': %w", title, err)
+	}
+
+	// gh prints the URL of the new pull request as its last line
+	url := strings.TrimSpace(string(output))
+	if i := strings.LastIndex(url, "\n"); i >= 0 {
+		url = url[i+1:]
+	}
+	m := prURLPattern.FindStringSubmatch(url)
+	if m == nil {
+		return PullRequest{}, fmt.Errorf("failed to parse PR URL from gh output: %q", url)
+	}
+	number, _ := strconv.Atoi(m[1])
+	return PullRequest{Number: number, URL: url}, nil
+}
+
+// GetIssue retrieves issue information using gh CLI
+func (g *GitHubCLI) GetIssue(ctx context.Context, issueNumber int) (string, error) {
+	cmd := g.command(ctx, "issue", "view", strconv.Itoa(issueNumber), "--json", "number,title,body,comments")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get issue #%d: %w", issueNumber, err)
+	}
+	return string(output), nil
+}
+
+// FindPR looks up the open pull request for a branch using gh CLI
+func (g *GitHubCLI) FindPR(ctx context.Context, branch string) (int, error) {
+	cmd := g.command(ctx, "pr", "list", "--head", branch, "--json", "number", "--limit", "1")
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to find PR for branch %s: %w", branch, err)
+	}
+
+	var prs []struct {
+		Number int `json:"number"`
+	}
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return 0, fmt.Errorf("failed to parse PR list: %w", err)
+	}
+	if len(prs) == 0 {
+		return 0, nil
+	}
+	return prs[0].Number, nil
+}
+
+// PRState looks up the state of the most recent pull request for a branch using gh CLI
+func (g *GitHubCLI) PRState(ctx context.Context, branch string) (string, error) {
+	cmd := g.command(ctx, "pr", "list", "--head", branch, "--state", "all", "--json", "state", "--limit", "1")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get PR state for branch %s: %w", branch, err)
+	}
+
+	var prs []struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return "", fmt.Errorf("failed to parse PR list: %w", err)
+	}
+	if len(prs) == 0 {
+		return "", nil
+	}
+	return prs[0].State, nil
+}
+
+// CurrentUser returns the login gh is authenticated as
+func (g *GitHubCLI) CurrentUser(ctx context.Context) (string, error) {
+	// Not g.command: gh api does not accept --repo
+	cmd := exec.CommandContext(ctx, "gh", "api", "user", "--jq", ".login")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current GitHub user: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 // FakeGitHub implements GitHub interface for testing
 type FakeGitHub struct {
-	prData     map[int]string   // prNumber -> PR info
-	comments   map[int][]string // prNumber -> list of comments
-	createdPRs []CreatedPR      // list of created PRs
+	prData     map[int]string    // prNumber -> PR info
+	comments   map[int][]string  // prNumber -> list of comments
+	createdPRs []CreatedPR       // list of created PRs
+	issues     map[int]string    // issueNumber -> issue info
+	branchPRs  map[string]int    // branch -> PR number
+	prStates   map[string]string // branch -> PR state
+	user       string            // returned by CurrentUser
 
 	// Error simulation flag
 	FailCreatePR bool
@@ -63,6 +212,7 @@ type FakeGitHub struct {
 type CreatedPR struct {
 	Title       string
 	Description string
+	Options     PROptions
 }
 
 // NewFakeGitHub creates a new FakeGitHub instance
@@ -71,6 +221,9 @@ func NewFakeGitHub() *FakeGitHub {
 		prData:     make(map[int]string),
 		comments:   make(map[int][]string),
 		createdPRs: []CreatedPR{},
+		issues:     make(map[int]string),
+		branchPRs:  make(map[string]int),
+		prStates:   make(map[string]string),
 	}
 }
 
@@ -80,7 +233,7 @@ func (f *FakeGitHub) SetPRInfo(prNumber int, info string) {
 }
 
 // GetPRInfo returns stored PR information
-func (f *FakeGitHub) GetPRInfo(prNumber int) (string, error) {
+func (f *FakeGitHub) GetPRInfo(ctx context.Context, prNumber int) (string, error) {
 	if info, exists := f.prData[prNumber]; exists {
 		return info, nil
 	}
@@ -88,7 +241,7 @@ func (f *FakeGitHub) GetPRInfo(prNumber int) (string, error) {
 }
 
 // PostComment adds a comment to the fake storage
-func (f *FakeGitHub) PostComment(prNumber int, body string) error {
+func (f *FakeGitHub) PostComment(ctx context.Context, prNumber int, body string) error {
 	if _, exists := f.comments[prNumber]; !exists {
 		f.comments[prNumber] = []string{}
 	}
@@ -97,15 +250,60 @@ func (f *FakeGitHub) PostComment(prNumber int, body string) error {
 }
 
 // CreatePR records a created pull request in fake storage
-func (f *FakeGitHub) CreatePR(title, description string) error {
+func (f *FakeGitHub) CreatePR(ctx context.Context, title, description string, options PROptions) (PullRequest, error) {
 	if f.FailCreatePR {
-		return fmt.Errorf("fake create PR failure")
+		return PullRequest{}, fmt.Errorf("fake create PR failure")
 	}
 	f.createdPRs = append(f.createdPRs, CreatedPR{
 		Title:       title,
 		Description: description,
+		Options:     options,
 	})
-	return nil
+	number := len(f.createdPRs)
+	return PullRequest{Number: number, URL: fmt.Sprintf("https://github.com/fake/repo/pull/%d", number)}, nil
+}
+
+// SetIssue sets the issue information for testing
+func (f *FakeGitHub) SetIssue(issueNumber int, info string) {
+	f.issues[issueNumber] = info
+}
+
+// GetIssue returns stored issue information
+func (f *FakeGitHub) GetIssue(ctx context.Context, issueNumber int) (string, error) {
+	if info, exists := f.issues[issueNumber]; exists {
+		return info, nil
+	}
+	return "", fmt.Errorf("issue #%d not found", issueNumber)
+}
+
+// SetBranchPR sets the PR number returned by FindPR for a branch
+func (f *FakeGitHub) SetBranchPR(branch string, prNumber int) {
+	f.branchPRs[branch] = prNumber
+}
+
+// FindPR returns the stored PR number for a branch, or 0
+func (f *FakeGitHub) FindPR(ctx context.Context, branch string) (int, error) {
+	return f.branchPRs[branch], nil
+}
+
+// SetPRState sets the PR state returned by PRState for a branch
+func (f *FakeGitHub) SetPRState(branch, state string) {
+	f.prStates[branch] = state
+}
+
+// PRState returns the stored PR state for a branch, or ""
+func (f *FakeGitHub) PRState(ctx context.Context, branch string) (string, error) {
+	return f.prStates[branch], nil
+}
+
+// CurrentUser returns the configured login
+func (f *FakeGitHub) CurrentUser(ctx context.Context) (string, error) {
+	return f.user, nil
+}
+
+// SetCurrentUser sets the login returned by CurrentUser (for testing)
+func (f *FakeGitHub) SetCurrentUser(login string) {
+	f.user = login
 }
 
 // GetComments returns all comments for a PR (for testing)
@@ -0,0 +1,980 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/go-git/go-git/v5/utils/binary"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// memoryWorktreePath is the path of the only worktree of an in-memory repository
+const memoryWorktreePath = "/"
+
+// GoGit implements LocalGit interface with the pure-Go go-git library. Status,
+// branch, remote, worktree and diff queries run in process. Everything that
+// writes to the repository is delegated to Fallback, because go-git skips
+// hooks and the user's global excludes; only an in-memory repository, which
+// has neither, is written in process. Like GitRunner, it works on the
+// repository of the current directory.
+type GoGit struct {
+	Fallback     LocalGit       // Usually a GitRunner; when nil, delegated operations fail
+	WorktreeRoot string         // Directory for new worktrees; empty means next to the main worktree
+	Commits      CommitSettings // Identity and sign-off of commits in memory; on disk, Fallback's settings apply
+
+	memory *git.Repository // Set by NewMemoryGoGit
+}
+
+// NewMemoryGoGit creates a GoGit on an empty repository held entirely in
+// memory, with a single worktree at "/"
+func NewMemoryGoGit() (*GoGit, error) {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create in-memory repository: %w", err)
+	}
+	return &GoGit{memory: repo}, nil
+}
+
+// Repository returns the in-memory repository, e.g. to add remotes in tests;
+// nil if the repository is on disk
+func (g *GoGit) Repository() *git.Repository {
+	return g.memory
+}
+
+// open returns the repository of the current directory
+func (g *GoGit) open() (*git.Repository, error) {
+	if g.memory != nil {
+		return g.memory, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository at %s: %w", dir, err)
+	}
+	return repo, nil
+}
+
+// worktree returns the worktree of the current directory
+func (g *GoGit) worktree() (*git.Repository, *git.Worktree, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	return repo, wt, nil
+}
+
+// fallback returns Fallback for an operation go-git does not support
+func (g *GoGit) fallback(operation string) (LocalGit, error) {
+	if g.Fallback == nil {
+		return nil, fmt.Errorf("%s is not supported by the go-git backend", operation)
+	}
+	return g.Fallback, nil
+}
+
+// CheckWorktreeExists checks if a worktree has exactly the given branch checked out
+func (g *GoGit) CheckWorktreeExists(ctx context.Context, branch string) (bool, error) {
+	worktrees, err := g.ListWorktrees(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, worktree := range worktrees {
+		if worktree.Branch == branch {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListWorktrees reads the main worktree and the linked worktrees registered
+// in the repository's worktrees directory; the main worktree comes first
+func (g *GoGit) ListWorktrees(ctx context.Context) ([]Worktree, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	if g.memory != nil {
+		worktree := Worktree{Path: memoryWorktreePath, Main: true}
+		if head, err := repo.Head(); err == nil {
+			worktree.Head = head.Hash().String()
+			if head.Name().IsBranch() {
+				worktree.Branch = head.Name().Short()
+			}
+		}
+		return []Worktree{worktree}, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	_, commonDir, err := findGitDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	main := Worktree{Path: commonDir, Main: true}
+	if filepath.Base(commonDir) == ".git" {
+		main.Path = filepath.Dir(commonDir)
+		main.Branch, main.Head = readWorktreeHEAD(repo, commonDir)
+	}
+	worktrees := []Worktree{main}
+
+	entries, err := os.ReadDir(filepath.Join(commonDir, "worktrees"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, entry := range entries {
+		gitDir := filepath.Join(commonDir, "worktrees", entry.Name())
+		dotGit, err := os.ReadFile(filepath.Join(gitDir, "gitdir"))
+		if err != nil {
+			continue
+		}
+		worktree := Worktree{Path: filepath.Dir(strings.TrimSpace(string(dotGit)))}
+		worktree.Branch, worktree.Head = readWorktreeHEAD(repo, gitDir)
+		worktrees = append(worktrees, worktree)
+	}
+	return worktrees, nil
+}
+
+// findGitDirs returns the git directory of the worktree containing dir and
+// the common git directory shared by all worktrees
+func findGitDirs(dir string) (gitDir, commonDir string, err error) {
+	for current := dir; ; current = filepath.Dir(current) {
+		dotGit := filepath.Join(current, ".git")
+		info, statErr := os.Stat(dotGit)
+		switch {
+		case statErr == nil && info.IsDir():
+			return dotGit, dotGit, nil
+		case statErr == nil:
+			content, err := os.ReadFile(dotGit)
+			if err != nil {
+				return "", "", fmt.Errorf("failed to read %s: %w", dotGit, err)
+			}
+			gitDir = strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(current, gitDir)
+			}
+			commonDir = gitDir
+			if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+				commonDir = strings.TrimSpace(string(common))
+				if !filepath.IsAbs(commonDir) {
+					commonDir = filepath.Join(gitDir, commonDir)
+				}
+			}
+			return gitDir, filepath.Clean(commonDir), nil
+		}
+
+		// A bare repository, such as a mirror, is its own git directory
+		if _, err := os.Stat(filepath.Join(current, "HEAD")); err == nil {
+			if _, err := os.Stat(filepath.Join(current, "objects")); err == nil {
+				return current, current, nil
+			}
+		}
+		if filepath.Dir(current) == current {
+			return "", "", fmt.Errorf("failed to find git directory for %s", dir)
+		}
+	}
+}
+
+// readWorktreeHEAD returns the branch and commit checked out according to the
+// HEAD file in gitDir; the branch is empty for a detached HEAD
+func readWorktreeHEAD(repo *git.Repository, gitDir string) (branch, head string) {
+	content, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "ref: ")
+	if !ok {
+		return "", strings.TrimSpace(string(content))
+	}
+	name := plumbing.ReferenceName(ref)
+	if resolved, err := repo.Reference(name, true); err == nil {
+		head = resolved.Hash().String()
+	}
+	return name.Short(), head
+}
+
+// CreateWorktree creates a new worktree through Fallback
+func (g *GoGit) CreateWorktree(ctx context.Context, branch, path string) error {
+	fallback, err := g.fallback("creating worktrees")
+	if err != nil {
+		return err
+	}
+	return fallback.CreateWorktree(ctx, branch, path)
+}
+
+// ChangeDirectory changes to the specified worktree directory
+func (g *GoGit) ChangeDirectory(ctx context.Context, path string) error {
+	if g.memory != nil {
+		if path != memoryWorktreePath {
+			return fmt.Errorf("failed to change directory to %s: an in-memory repository only has %s", path, memoryWorktreePath)
+		}
+		return nil
+	}
+	if err := os.Chdir(path); err != nil {
+		return fmt.Errorf("failed to change directory to %s: %w", path, err)
+	}
+	return nil
+}
+
+// CommitAndPush commits all changes and pushes them through Fallback
+func (g *GoGit) CommitAndPush(ctx context.Context, message string) error {
+	committed, err := g.Commit(ctx, message)
+	if err != nil || !committed {
+		return err
+	}
+	return g.Push(ctx)
+}
+
+// GetWorktreePath returns the path of the worktree that has the branch
+// checked out, or the path for a new one as GitRunner does
+func (g *GoGit) GetWorktreePath(ctx context.Context, branch string) (string, error) {
+	worktrees, err := g.ListWorktrees(ctx)
+	if err != nil {
+		return "", err
+	}
+	return worktreePath(worktrees, g.WorktreeRoot, branch)
+}
+
+// IsGitRepository checks if the current directory is in a git repository
+func (g *GoGit) IsGitRepository(ctx context.Context) (bool, error) {
+	if _, err := g.open(); err != nil {
+		if errors.Is(err, git.ErrRepositoryNotExists) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetGitHubRepository extracts GitHub owner/repo from the origin remote
+func (g *GoGit) GetGitHubRepository(ctx context.Context) (owner, repo string, err error) {
+	repository, err := g.open()
+	if err != nil {
+		return "", "", err
+	}
+	remote, err := repository.Remote("origin")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get remote origin URL: %w", err)
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", "", fmt.Errorf("remote origin has no URL")
+	}
+	return parseGitHubRemoteURL(urls[0])
+}
+
+// CreateBranch creates a branch in a new worktree through Fallback
+func (g *GoGit) CreateBranch(ctx context.Context, branchName, startPoint, path string) error {
+	fallback, err := g.fallback("creating worktrees")
+	if err != nil {
+		return err
+	}
+	return fallback.CreateBranch(ctx, branchName, startPoint, path)
+}
+
+// WriteFile writes content to a file at the specified path, creating its directory
+func (g *GoGit) WriteFile(ctx context.Context, path, content string) error {
+	if g.memory == nil {
+		return (&GitRunner{}).WriteFile(ctx, path, content)
+	}
+	_, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	if err := util.WriteFile(wt.Filesystem, path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadFile reads the content of a file at the specified path
+func (g *GoGit) ReadFile(ctx context.Context, path string) (string, error) {
+	if g.memory == nil {
+		return (&GitRunner{}).ReadFile(ctx, path)
+	}
+	_, wt, err := g.worktree()
+	if err != nil {
+		return "", err
+	}
+	content, err := util.ReadFile(wt.Filesystem, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	return string(content), nil
+}
+
+// PushBranchUpstream pushes a new branch through Fallback
+func (g *GoGit) PushBranchUpstream(ctx context.Context, branchName string) error {
+	fallback, err := g.fallback("pushing")
+	if err != nil {
+		return err
+	}
+	return fallback.PushBranchUpstream(ctx, branchName)
+}
+
+// BranchExists checks if a local branch exists
+func (g *GoGit) BranchExists(ctx context.Context, branchName string) (bool, error) {
+	repo, err := g.open()
+	if err != nil {
+		return false, err
+	}
+	_, err = repo.Reference(plumbing.NewBranchReferenceName(branchName), false)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up branch %s: %w", branchName, err)
+	}
+	return true, nil
+}
+
+// HeadSHA returns the commit SHA checked out in the current directory
+func (g *GoGit) HeadSHA(ctx context.Context) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	return head.Hash().String(), nil
+}
+
+// CreateWorktreeAt creates a worktree at a start point through Fallback
+func (g *GoGit) CreateWorktreeAt(ctx context.Context, branch, path, startPoint string) error {
+	fallback, err := g.fallback("creating worktrees")
+	if err != nil {
+		return err
+	}
+	return fallback.CreateWorktreeAt(ctx, branch, path, startPoint)
+}
+
+// RemoveWorktree removes a worktree through Fallback
+func (g *GoGit) RemoveWorktree(ctx context.Context, path string) error {
+	fallback, err := g.fallback("removing worktrees")
+	if err != nil {
+		return err
+	}
+	return fallback.RemoveWorktree(ctx, path)
+}
+
+// StageAll stages all changes, including untracked files and deletions, through Fallback
+func (g *GoGit) StageAll(ctx context.Context) error {
+	if g.memory == nil {
+		fallback, err := g.fallback("staging")
+		if err != nil {
+			return err
+		}
+		return fallback.StageAll(ctx)
+	}
+	_, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	return nil
+}
+
+// DiffStat summarizes the staged changes relative to the given base commit
+func (g *GoGit) DiffStat(ctx context.Context, base string) (DiffStat, error) {
+	patch, err := g.stagedPatch(base)
+	if err != nil {
+		return DiffStat{}, err
+	}
+	var stat DiffStat
+	for _, filePatch := range patch {
+		stat.Files++
+		for _, chunk := range filePatch.Chunks() {
+			switch chunk.Type() {
+			case fdiff.Add:
+				stat.Additions += countLines(chunk.Content())
+			case fdiff.Delete:
+				stat.Deletions += countLines(chunk.Content())
+			}
+		}
+	}
+	return stat, nil
+}
+
+// StagedDiff returns the unified diff of the staged changes relative to the
+// given base commit. Unlike GitRunner, renames appear as a deletion and an
+// addition.
+func (g *GoGit) StagedDiff(ctx context.Context, base string) (string, error) {
+	patch, err := g.stagedPatch(base)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := fdiff.NewUnifiedEncoder(&b, fdiff.DefaultContextLines).Encode(patch); err != nil {
+		return "", fmt.Errorf("failed to encode diff: %w", err)
+	}
+	return b.String(), nil
+}
+
+// stagedPatch compares the index with the tree of the base commit
+func (g *GoGit) stagedPatch(base string) (stagedPatch, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", base, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", base, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of %s: %w", base, err)
+	}
+
+	from := make(map[string]*patchFile)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		from[f.Name] = &patchFile{hash: f.Hash, mode: f.Mode, path: f.Name}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", base, err)
+	}
+	index, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	to := make(map[string]*patchFile)
+	for _, entry := range index.Entries {
+		to[entry.Name] = &patchFile{hash: entry.Hash, mode: entry.Mode, path: entry.Name}
+	}
+
+	paths := make(map[string]bool)
+	for path := range from {
+		paths[path] = true
+	}
+	for path := range to {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var patch stagedPatch
+	for _, path := range sorted {
+		before, after := from[path], to[path]
+		if before != nil && after != nil && before.hash == after.hash && before.mode == after.mode {
+			continue
+		}
+		filePatch, err := newFilePatch(repo, before, after)
+		if err != nil {
+			return nil, err
+		}
+		patch = append(patch, filePatch)
+	}
+	return patch, nil
+}
+
+// newFilePatch diffs the contents of two versions of a file, either of which may be nil
+func newFilePatch(repo *git.Repository, from, to *patchFile) (*filePatch, error) {
+	patch := &filePatch{from: from, to: to}
+	var contents [2][]byte
+	for i, file := range []*patchFile{from, to} {
+		if file == nil || file.mode == filemode.Submodule {
+			continue
+		}
+		content, err := readBlob(repo, file.hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.path, err)
+		}
+		if isBinary, _ := binary.IsBinary(bytes.NewReader(content)); isBinary {
+			patch.binary = true
+			return patch, nil
+		}
+		contents[i] = content
+	}
+
+	operations := map[diffmatchpatch.Operation]fdiff.Operation{
+		diffmatchpatch.DiffEqual:  fdiff.Equal,
+		diffmatchpatch.DiffInsert: fdiff.Add,
+		diffmatchpatch.DiffDelete: fdiff.Delete,
+	}
+	for _, d := range diff.Do(string(contents[0]), string(contents[1])) {
+		patch.chunks = append(patch.chunks, patchChunk{content: d.Text, operation: operations[d.Type]})
+	}
+	return patch, nil
+}
+
+// readBlob returns the content of a blob
+func readBlob(repo *git.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// countLines counts the lines of a diff chunk, including an unterminated last line
+func countLines(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// stagedPatch implements diff.Patch for the staged changes
+type stagedPatch []*filePatch
+
+func (p stagedPatch) FilePatches() []fdiff.FilePatch {
+	patches := make([]fdiff.FilePatch, len(p))
+	for i, filePatch := range p {
+		patches[i] = filePatch
+	}
+	return patches
+}
+
+func (p stagedPatch) Message() string { return "" }
+
+// filePatch implements diff.FilePatch
+type filePatch struct {
+	from, to *patchFile
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (p *filePatch) IsBinary() bool { return p.binary }
+
+func (p *filePatch) Files() (from, to fdiff.File) {
+	// Nil pointers must become nil interfaces for the encoder to see added and deleted files
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+func (p *filePatch) Chunks() []fdiff.Chunk { return p.chunks }
+
+// patchFile implements diff.File
+type patchFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	path string
+}
+
+func (f *patchFile) Hash() plumbing.Hash     { return f.hash }
+func (f *patchFile) Mode() filemode.FileMode { return f.mode }
+func (f *patchFile) Path() string            { return f.path }
+
+// patchChunk implements diff.Chunk
+type patchChunk struct {
+	content   string
+	operation fdiff.Operation
+}
+
+func (c patchChunk) Content() string       { return c.content }
+func (c patchChunk) Type() fdiff.Operation { return c.operation }
+
+// Commit stages all changes and commits them through Fallback, reporting
+// whether a commit was created. In memory, the commit is created in process;
+// without a configured identity the author is taken from the git configuration.
+func (g *GoGit) Commit(ctx context.Context, message string) (bool, error) {
+	if g.memory == nil || g.Commits.SigningFormat != "" {
+		fallback, err := g.fallback("committing")
+		if err != nil {
+			return false, err
+		}
+		return fallback.Commit(ctx, message)
+	}
+	if err := g.StageAll(ctx); err != nil {
+		return false, err
+	}
+	repo, wt, err := g.worktree()
+	if err != nil {
+		return false, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to check git status: %w", err)
+	}
+	if status.IsClean() {
+		return false, nil
+	}
+	options, err := g.commitOptions(repo)
+	if err != nil {
+		return false, err
+	}
+	if g.Commits.SignOff {
+		message = appendTrailer(message, "Signed-off-by", fmt.Sprintf("%s <%s>", options.Committer.Name, options.Committer.Email))
+	}
+	if _, err := wt.Commit(message, options); err != nil {
+		return false, fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return true, nil
+}
+
+// commitOptions returns the author and committer of a new commit, filling
+// in the git configuration where Commits leaves them empty
+func (g *GoGit) commitOptions(repo *git.Repository) (*git.CommitOptions, error) {
+	cfg, err := repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git config: %w", err)
+	}
+	now := time.Now()
+	author := &object.Signature{Name: cfg.User.Name, Email: cfg.User.Email, When: now}
+	if cfg.Author.Name != "" {
+		author.Name, author.Email = cfg.Author.Name, cfg.Author.Email
+	}
+	if g.Commits.AuthorName != "" {
+		author.Name, author.Email = g.Commits.AuthorName, g.Commits.AuthorEmail
+	}
+	committer := &object.Signature{Name: author.Name, Email: author.Email, When: now}
+	if cfg.Committer.Name != "" {
+		committer.Name, committer.Email = cfg.Committer.Name, cfg.Committer.Email
+	}
+	if name, email := g.Commits.committer(); name != "" {
+		committer.Name, committer.Email = name, email
+	}
+	return &git.CommitOptions{Author: author, Committer: committer}, nil
+}
+
+// Push pushes the current branch through Fallback
+func (g *GoGit) Push(ctx context.Context) error {
+	fallback, err := g.fallback("pushing")
+	if err != nil {
+		return err
+	}
+	return fallback.Push(ctx)
+}
+
+// ResetHard resets the current branch and worktree to the given commit through Fallback
+func (g *GoGit) ResetHard(ctx context.Context, ref string) error {
+	if g.memory == nil {
+		fallback, err := g.fallback("resetting")
+		if err != nil {
+			return err
+		}
+		return fallback.ResetHard(ctx, ref)
+	}
+	repo, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if err := wt.Reset(&git.ResetOptions{Commit: *hash, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", ref, err)
+	}
+	return nil
+}
+
+// DefaultBranch returns the branch origin/HEAD points to, asking Fallback if it is not known locally
+func (g *GoGit) DefaultBranch(ctx context.Context) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false)
+	if err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().String(), "refs/remotes/origin/"), nil
+	}
+	fallback, err := g.fallback("asking the remote for its default branch")
+	if err != nil {
+		return "", err
+	}
+	return fallback.DefaultBranch(ctx)
+}
+
+// FetchBranch fetches a branch through Fallback
+func (g *GoGit) FetchBranch(ctx context.Context, branch string) error {
+	fallback, err := g.fallback("fetching")
+	if err != nil {
+		return err
+	}
+	return fallback.FetchBranch(ctx, branch)
+}
+
+// CurrentDirectory returns the current working directory
+func (g *GoGit) CurrentDirectory(ctx context.Context) (string, error) {
+	if g.memory != nil {
+		return memoryWorktreePath, nil
+	}
+	return (&GitRunner{}).CurrentDirectory(ctx)
+}
+
+// SyncMirror clones or fetches a mirror through Fallback
+func (g *GoGit) SyncMirror(ctx context.Context, url, path string) error {
+	fallback, err := g.fallback("syncing mirrors")
+	if err != nil {
+		return err
+	}
+	return fallback.SyncMirror(ctx, url, path)
+}
+
+// Status describes the current worktree relative to origin/<branch>. It
+// walks the history of both commits to count the commits between them.
+func (g *GoGit) Status(ctx context.Context, branch string) (WorktreeStatus, error) {
+	var status WorktreeStatus
+	repo, wt, err := g.worktree()
+	if err != nil {
+		return status, err
+	}
+
+	fileStatus, err := wt.Status()
+	if err != nil {
+		return status, fmt.Errorf("failed to get status: %w", err)
+	}
+	paths := make([]string, 0, len(fileStatus))
+	for path, s := range fileStatus {
+		if s.Staging != git.Unmodified || s.Worktree != git.Unmodified {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		s := fileStatus[path]
+		status.Changes = append(status.Changes, fmt.Sprintf("%c%c %s", s.Staging, s.Worktree, path))
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return status, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head.Name().IsBranch() {
+		status.Branch = head.Name().Short()
+	}
+
+	if g.memory == nil {
+		dir, err := os.Getwd()
+		if err != nil {
+			return status, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		gitDir, _, err := findGitDirs(dir)
+		if err != nil {
+			return status, err
+		}
+		status.Operation = operationInProgress(gitDir)
+	}
+
+	remote, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return status, fmt.Errorf("failed to compare with origin/%s: %w", branch, err)
+	}
+	status.Ahead, status.Behind, err = countDivergence(repo, head.Hash(), remote.Hash())
+	if err != nil {
+		return status, fmt.Errorf("failed to compare with origin/%s: %w", branch, err)
+	}
+	return status, nil
+}
+
+// countDivergence counts the commits reachable only from local and only from remote
+func countDivergence(repo *git.Repository, local, remote plumbing.Hash) (ahead, behind int, err error) {
+	if local == remote {
+		return 0, 0, nil
+	}
+	localCommits, err := ancestors(repo, local)
+	if err != nil {
+		return 0, 0, err
+	}
+	remoteCommits, err := ancestors(repo, remote)
+	if err != nil {
+		return 0, 0, err
+	}
+	for hash := range localCommits {
+		if !remoteCommits[hash] {
+			ahead++
+		}
+	}
+	for hash := range remoteCommits {
+		if !localCommits[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// CommitsSince returns the commits reachable from HEAD but not from base, oldest first
+func (g *GoGit) CommitsSince(ctx context.Context, base string) ([]CommitInfo, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	baseHash, err := repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", base, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	seen, err := ancestors(repo, *baseHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits since %s: %w", base, err)
+	}
+
+	commits := []CommitInfo{}
+	err = object.NewCommitPreorderIter(headCommit, seen, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, newCommitInfo(c.Hash.String(), c.Message))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits since %s: %w", base, err)
+	}
+	slices.Reverse(commits)
+	return commits, nil
+}
+
+// ResetSoft moves the current branch to the given commit through Fallback, keeping all changes staged
+func (g *GoGit) ResetSoft(ctx context.Context, ref string) error {
+	if g.memory == nil {
+		fallback, err := g.fallback("resetting")
+		if err != nil {
+			return err
+		}
+		return fallback.ResetSoft(ctx, ref)
+	}
+	repo, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if err := wt.Reset(&git.ResetOptions{Commit: *hash, Mode: git.SoftReset}); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", ref, err)
+	}
+	return nil
+}
+
+// Revert stages a revert through Fallback
+func (g *GoGit) Revert(ctx context.Context, base, commit string) error {
+	fallback, err := g.fallback("reverting")
+	if err != nil {
+		return err
+	}
+	return fallback.Revert(ctx, base, commit)
+}
+
+// PushWithLease force-pushes the current branch through Fallback
+func (g *GoGit) PushWithLease(ctx context.Context, expected string) error {
+	fallback, err := g.fallback("pushing")
+	if err != nil {
+		return err
+	}
+	return fallback.PushWithLease(ctx, expected)
+}
+
+// ancestors returns the commit and all commits reachable from it
+func ancestors(repo *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(commit, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	return seen, err
+}
+
+// Stash stashes changes through Fallback
+func (g *GoGit) Stash(ctx context.Context, message string) error {
+	fallback, err := g.fallback("stashing")
+	if err != nil {
+		return err
+	}
+	return fallback.Stash(ctx, message)
+}
+
+// AbortOperation aborts an unfinished operation through Fallback
+func (g *GoGit) AbortOperation(ctx context.Context, operation string) error {
+	fallback, err := g.fallback("aborting " + operation)
+	if err != nil {
+		return err
+	}
+	return fallback.AbortOperation(ctx, operation)
+}
+
+// CheckoutBranch checks out an existing branch in the current worktree through Fallback
+func (g *GoGit) CheckoutBranch(ctx context.Context, branch string) error {
+	if g.memory == nil {
+		fallback, err := g.fallback("checking out")
+		if err != nil {
+			return err
+		}
+		return fallback.CheckoutBranch(ctx, branch)
+	}
+	_, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch)}); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", branch, err)
+	}
+	return nil
+}
+
+// Clean removes untracked files and directories through Fallback
+func (g *GoGit) Clean(ctx context.Context) error {
+	if g.memory == nil {
+		fallback, err := g.fallback("cleaning")
+		if err != nil {
+			return err
+		}
+		return fallback.Clean(ctx)
+	}
+	_, wt, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	if err := wt.Clean(&git.CleanOptions{Dir: true}); err != nil {
+		return fmt.Errorf("failed to remove untracked files: %w", err)
+	}
+	return nil
+}
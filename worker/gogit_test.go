@@ -0,0 +1,222 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// newMemoryTestGit creates an in-memory repository with one commit on master
+// that origin/master points to
+func newMemoryTestGit(t *testing.T) *GoGit {
+	t.Helper()
+	ctx := context.Background()
+	g, err := NewMemoryGoGit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := g.Repository()
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name = "test"
+	cfg.User.Email = "test@localhost"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/acme/widgets.git"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.WriteFile(ctx, "main.go", "package main\n"); err != nil {
+		t.Fatal(err)
+	}
+	if committed, err := g.Commit(ctx, "Initial commit"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "master"), head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGoGitMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	g := newMemoryTestGit(t)
+
+	owner, repo, err := g.GetGitHubRepository(ctx)
+	if err != nil || owner != "acme" || repo != "widgets" {
+		t.Errorf("GetGitHubRepository = %q, %q, %v", owner, repo, err)
+	}
+	if exists, _ := g.BranchExists(ctx, "master"); !exists {
+		t.Error("Expected master to exist")
+	}
+	if exists, _ := g.CheckWorktreeExists(ctx, "master"); !exists {
+		t.Error("Expected master to be checked out")
+	}
+
+	if err := g.WriteFile(ctx, "notes.txt", "local\n"); err != nil {
+		t.Fatal(err)
+	}
+	if committed, err := g.Commit(ctx, "Local commit"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+	if committed, err := g.Commit(ctx, "Nothing to commit"); err != nil || committed {
+		t.Errorf("Expected no commit without changes, got %v, %v", committed, err)
+	}
+
+	g.WriteFile(ctx, "main.go", "package main\n\nfunc main() {}\n")
+	g.WriteFile(ctx, "leftover.txt", "x\n")
+	status, err := g.Status(ctx, "master")
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	want := WorktreeStatus{Branch: "master", Changes: []string{"?? leftover.txt", " M main.go"}, Ahead: 1}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("Status = %+v, want %+v", status, want)
+	}
+
+	if err := g.ResetHard(ctx, "origin/master"); err != nil {
+		t.Fatalf("ResetHard failed: %v", err)
+	}
+	if err := g.Clean(ctx); err != nil {
+		t.Fatalf("Clean failed: %v", err)
+	}
+	status, _ = g.Status(ctx, "master")
+	if len(status.Problems("master")) != 0 {
+		t.Errorf("Expected clean worktree after reset, got %+v", status)
+	}
+}
+
+func TestGoGitDelegatesWrites(t *testing.T) {
+	ctx := context.Background()
+	fakeGit := NewFakeLocalGit()
+	g := &GoGit{Fallback: fakeGit}
+
+	if committed, err := g.Commit(ctx, "fix: parser"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+	if err := g.ResetHard(ctx, "origin/feature"); err != nil {
+		t.Fatalf("ResetHard failed: %v", err)
+	}
+	if commits := fakeGit.GetCommits(); len(commits) != 1 || commits[0] != "fix: parser" {
+		t.Errorf("Expected the commit to be created by Fallback, got %v", commits)
+	}
+	if resets := fakeGit.GetResets(); len(resets) != 1 || resets[0] != "origin/feature" {
+		t.Errorf("Expected the reset to be done by Fallback, got %v", resets)
+	}
+
+	if err := (&GoGit{}).StageAll(ctx); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Expected staging without Fallback to fail, got %v", err)
+	}
+}
+
+func TestGoGitStagedDiffWithPolicy(t *testing.T) {
+	ctx := context.Background()
+	g := newMemoryTestGit(t)
+	g.WriteFile(ctx, "main.go", "package main\n\nfunc main() {}\n")
+	g.WriteFile(ctx, "docs/notes.md", "x\n")
+
+	w := &Worker{Git: g, Policy: &Policy{ProtectedPaths: []string{"main.go"}}}
+	violations, _, err := w.checkChanges(ctx, "HEAD")
+	if err != nil {
+		t.Fatalf("checkChanges failed: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Path != "main.go" {
+		t.Errorf("Expected protected path violation for main.go, got %+v", violations)
+	}
+
+	stat, err := g.DiffStat(ctx, "HEAD")
+	if err != nil {
+		t.Fatalf("DiffStat failed: %v", err)
+	}
+	if stat != (DiffStat{Files: 2, Additions: 3}) {
+		t.Errorf("DiffStat = %+v", stat)
+	}
+
+	diff, _ := g.StagedDiff(ctx, "HEAD")
+	changes := parseUnifiedDiff(diff)
+	if len(changes) != 2 || changes[0].Path != "docs/notes.md" || !changes[0].Added || changes[1].AddedLines[0].Number != 2 {
+		t.Errorf("Unexpected parsed diff %+v from:\n%s", changes, diff)
+	}
+}
+
+func TestGoGitMatchesGitRunner(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	tmp := t.TempDir()
+	seed := filepath.Join(tmp, "seed")
+	if err := os.Mkdir(seed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initEvalRepo(ctx, seed); err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(tmp, "repo")
+	for _, step := range []struct {
+		dir  string
+		args []string
+	}{
+		{tmp, []string{"clone", "--quiet", seed, repo}},
+		{repo, []string{"remote", "set-url", "origin", "git@github.com:acme/widgets.git"}},
+		{repo, []string{"worktree", "add", "--quiet", "-b", "feature", filepath.Join(tmp, "repo-feature")}},
+	} {
+		if err := runGit(ctx, step.dir, step.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(repo, "leftover.txt"), []byte("x"), 0644)
+
+	original, _ := os.Getwd()
+	defer os.Chdir(original)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	runner, goGit := &GitRunner{}, &GoGit{}
+	wantWorktrees, err := runner.ListWorktrees(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktrees, err := goGit.ListWorktrees(ctx)
+	if err != nil || !reflect.DeepEqual(worktrees, wantWorktrees) {
+		t.Errorf("ListWorktrees = %+v, %v, want %+v", worktrees, err, wantWorktrees)
+	}
+
+	branch := wantWorktrees[0].Branch
+	wantStatus, err := runner.Status(ctx, branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := goGit.Status(ctx, branch)
+	if err != nil || !reflect.DeepEqual(status, wantStatus) {
+		t.Errorf("Status = %+v, %v, want %+v", status, err, wantStatus)
+	}
+
+	owner, name, err := goGit.GetGitHubRepository(ctx)
+	if err != nil || owner != "acme" || name != "widgets" {
+		t.Errorf("GetGitHubRepository = %q, %q, %v", owner, name, err)
+	}
+
+	if err := goGit.ChangeDirectory(ctx, filepath.Join(tmp, "repo-feature")); err != nil {
+		t.Fatal(err)
+	}
+	head, err := goGit.HeadSHA(ctx)
+	if err != nil || head != wantWorktrees[1].Head {
+		t.Errorf("HeadSHA in linked worktree = %q, %v, want %q", head, err, wantWorktrees[1].Head)
+	}
+	if _, err := goGit.Status(ctx, "feature"); err == nil || !strings.Contains(err.Error(), "origin/feature") {
+		t.Errorf("Expected missing origin/feature to be reported, got %v", err)
+	}
+}
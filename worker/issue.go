@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxIssueSlugLength bounds the title part of branch names derived from issues
+const maxIssueSlugLength = 40
+
+// Issue is a GitHub issue that work is started from
+type Issue struct {
+	Number   int         `json:"number"`
+	Title    string      `json:"title"`
+	Body     string      `json:"body"`
+	Comments []PRComment `json:"comments"`
+}
+
+// FetchIssue retrieves and parses an issue
+func (w *Worker) FetchIssue(ctx context.Context, issueNumber int) (*Issue, error) {
+	info, err := w.GitHub.GetIssue(ctx, issueNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+
+	var issue Issue
+	if err := json.Unmarshal([]byte(info), &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse issue #%d: %w", issueNumber, err)
+	}
+	if issue.Number == 0 {
+		issue.Number = issueNumber
+	}
+	return &issue, nil
+}
+
+// IssueBranchName derives a branch name such as "issue-123-fix-login-redirect" from an issue
+func IssueBranchName(issue *Issue) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(issue.Title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+
+	name := slug.String()
+	if len(name) > maxIssueSlugLength {
+		name = strings.TrimRight(name[:maxIssueSlugLength], "-")
+	}
+	if name == "" {
+		return fmt.Sprintf("issue-%d", issue.Number)
+	}
+	return fmt.Sprintf("issue-%d-%s", issue.Number, name)
+}
+
+// StartIssue starts the task with instructions taken from the issue and
+// opens a pull request that closes the issue
+func (w *Worker) StartIssue(ctx context.Context, task StartTask, issue *Issue) (PullRequest, error) {
+	task.Instructions = formatIssueInstructions(issue)
+	if task.Title == "" {
+		task.Title = issue.Title
+	}
+	description := startDescription(task.Branch) + fmt.Sprintf("\n\nCloses #%d", issue.Number)
+	return w.start(ctx, task, task.Title, description)
+}
+
+// formatIssueInstructions renders an issue and its discussion as an instructions file
+func formatIssueInstructions(issue *Issue) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", issue.Title)
+	fmt.Fprintf(&b, "Issue #%d\n\n", issue.Number)
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		b.WriteString(body + "\n")
+	}
+	if len(issue.Comments) > 0 {
+		b.WriteString("\n## Comments\n")
+		for _, comment := range issue.Comments {
+			fmt.Fprintf(&b, "\n**@%s:**\n\n%s\n", comment.Author.Login, strings.TrimSpace(comment.Body))
+		}
+	}
+	return b.String()
+}
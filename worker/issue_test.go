@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIssueBranchName(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Fix login redirect", "issue-123-fix-login-redirect"},
+		{"  [Bug] Crash on `kratt worker run`!  ", "issue-123-bug-crash-on-kratt-worker-run"},
+		{"Support a very long title that goes on and on beyond forty characters", "issue-123-support-a-very-long-title-that-goes-on-a"},
+		{"🚀", "issue-123"},
+	}
+
+	for _, tt := range tests {
+		if got := IssueBranchName(&Issue{Number: 123, Title: tt.title}); got != tt.want {
+			t.Errorf("IssueBranchName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerStartIssue(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetIssue(123, `{"number": 123, "title": "Fix login redirect", "body": "Users end up on /home.", "comments": [{"author": {"login": "alice"}, "body": "Only with SSO."}]}`)
+
+	w := &Worker{Git: fakeGit, GitHub: fakeGitHub, Runner: NewFakeCommandRunner()}
+
+	issue, err := w.FetchIssue(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("FetchIssue failed: %v", err)
+	}
+	task := StartTask{Branch: IssueBranchName(issue)}
+	if _, err := w.StartIssue(context.Background(), task, issue); err != nil {
+		t.Fatalf("StartIssue failed: %v", err)
+	}
+
+	instructions := fakeGit.GetWrittenFiles()["docs/issue-123-fix-login-redirect-instructions.md"]
+	for _, want := range []string{"# Fix login redirect", "Users end up on /home.", "**@alice:**", "Only with SSO."} {
+		if !strings.Contains(instructions, want) {
+			t.Errorf("Expected instructions to contain %q, got:\n%s", want, instructions)
+		}
+	}
+
+	prs := fakeGitHub.GetCreatedPRs()
+	if len(prs) != 1 || prs[0].Title != "Fix login redirect" || !strings.HasSuffix(prs[0].Description, "Closes #123") {
+		t.Errorf("Unexpected pull request: %+v", prs)
+	}
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Phases of a worker run with independent deadlines
+const (
+	PhaseSetup = "setup"
+	PhaseAgent = "agent"
+	PhaseLint  = "lint"
+	PhaseTest  = "test"
+	PhasePush  = "push"
+)
+
+// PhaseTimeouts configures a deadline per phase; zero values fall back to Worker.Deadline
+type PhaseTimeouts struct {
+	Setup time.Duration
+	Agent time.Duration
+	Lint  time.Duration
+	Test  time.Duration
+	Push  time.Duration
+}
+
+// PhaseTimeoutError reports that a phase exceeded its deadline
+type PhaseTimeoutError struct {
+	Phase   string
+	Timeout time.Duration
+}
+
+// Error implements the error interface
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("%s phase timed out after %s", e.Phase, e.Timeout)
+}
+
+// phaseTimeout returns the deadline configured for a phase
+func (w *Worker) phaseTimeout(phase string) time.Duration {
+	var timeout time.Duration
+	switch phase {
+	case PhaseSetup:
+		timeout = w.Timeouts.Setup
+	case PhaseAgent:
+		timeout = w.Timeouts.Agent
+	case PhaseLint:
+		timeout = w.Timeouts.Lint
+	case PhaseTest:
+		timeout = w.Timeouts.Test
+	case PhasePush:
+		timeout = w.Timeouts.Push
+	}
+	if timeout == 0 {
+		timeout = w.Deadline
+	}
+	return timeout
+}
+
+// phaseContext returns a context derived from ctx that expires after the phase's deadline
+func (w *Worker) phaseContext(ctx context.Context, phase string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, w.phaseTimeout(phase))
+}
+
+// cleanupTimeout bounds cleanup work done after a run was cancelled or timed out
+const cleanupTimeout = 30 * time.Second
+
+// cleanupContext returns a context for cleanup work that outlives the cancellation of ctx
+func cleanupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+}
+
+// phaseError turns an error caused by the phase context expiring into a PhaseTimeoutError
+func (w *Worker) phaseError(phase string, ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &PhaseTimeoutError{Phase: phase, Timeout: w.phaseTimeout(phase)}
+	}
+	return err
+}
+
+// runPhase runs fn under the phase's deadline
+func (w *Worker) runPhase(ctx context.Context, phase string, fn func(ctx context.Context) error) error {
+	ctx, cancel := w.phaseContext(ctx, phase)
+	defer cancel()
+
+	return w.phaseError(phase, ctx, fn(ctx))
+}
+
+// formatTimeoutComment formats a comment reporting a phase timeout
+func formatTimeoutComment(err *PhaseTimeoutError) string {
+	return fmt.Sprintf("## Kratt Worker Results\n\n⏱️ **Timed out:** the %s phase exceeded its %s deadline.\n", err.Phase, err.Timeout)
+}
+
+// formatCancelledComment formats a comment reporting a cancelled run
+func formatCancelledComment(run *RunRecord) string {
+	return fmt.Sprintf("## Kratt Worker Results\n\n🛑 **Cancelled:** run `%s` was cancelled before it finished.\n", run.ID)
+}
@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerPhaseTimeout(t *testing.T) {
+	w := &Worker{
+		Deadline: 30 * time.Minute,
+		Timeouts: PhaseTimeouts{Agent: 20 * time.Minute, Test: 5 * time.Minute},
+	}
+
+	tests := map[string]time.Duration{
+		PhaseSetup: 30 * time.Minute,
+		PhaseAgent: 20 * time.Minute,
+		PhaseLint:  30 * time.Minute,
+		PhaseTest:  5 * time.Minute,
+		PhasePush:  30 * time.Minute,
+	}
+	for phase, expected := range tests {
+		if got := w.phaseTimeout(phase); got != expected {
+			t.Errorf("phaseTimeout(%s) = %s, want %s", phase, got, expected)
+		}
+	}
+}
+
+func TestWorkerProcessPRAgentPhaseTimeout(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(3, `{"headRefName": "feature"}`)
+
+	agent := NewFakeAgent("slow")
+	agent.Block = true
+
+	w := &Worker{
+		LintCommand: []string{"go", "vet", "./..."},
+		TestCommand: []string{"go", "test", "./..."},
+		Deadline:    5 * time.Second,
+		Timeouts:    PhaseTimeouts{Agent: 10 * time.Millisecond},
+		Agent:       agent,
+		Git:         fakeGit,
+		GitHub:      fakeGitHub,
+		Runner:      NewFakeCommandRunner(),
+	}
+
+	err := w.ProcessPR(context.Background(), 3)
+	if err == nil || !strings.Contains(err.Error(), "agent phase timed out after 10ms") {
+		t.Fatalf("Expected agent phase timeout, got %v", err)
+	}
+
+	comments := fakeGitHub.GetComments(3)
+	if len(comments) != 1 || !strings.Contains(comments[0], "⏱️ **Timed out:** the agent phase exceeded its 10ms deadline") {
+		t.Errorf("Expected timeout comment, got %v", comments)
+	}
+	if len(fakeGit.GetCommits()) != 0 {
+		t.Error("Expected nothing to be committed after a timeout")
+	}
+}
+
+func TestFormatCheckStatusTimeout(t *testing.T) {
+	status := formatCheckStatus(&PhaseTimeoutError{Phase: PhaseTest, Timeout: 5 * time.Minute})
+	if status != "⏱️ **Timed out** after 5m0s\n" {
+		t.Errorf("Unexpected status for timeout: %q", status)
+	}
+}
+
+func TestWorkerProcessPRCancelled(t *testing.T) {
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(4, `{"headRefName": "feature"}`)
+
+	agent := NewFakeAgent("slow")
+	agent.Block = true
+
+	runs := NewFakeRunStore()
+	w := &Worker{
+		LintCommand: []string{"go", "vet", "./..."},
+		TestCommand: []string{"go", "test", "./..."},
+		Deadline:    5 * time.Second,
+		Agent:       agent,
+		Runs:        runs,
+		Git:         NewFakeLocalGit(),
+		GitHub:      fakeGitHub,
+		Runner:      NewFakeCommandRunner(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := w.ProcessPR(ctx, 4)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected cancellation error, got %v", err)
+	}
+
+	history, _ := runs.ListRuns()
+	if len(history) != 1 || history[0].Status != RunStatusCancelled {
+		t.Fatalf("Expected cancelled run to be recorded, got %+v", history)
+	}
+
+	comments := fakeGitHub.GetComments(4)
+	if len(comments) != 1 || !strings.Contains(comments[0], "🛑 **Cancelled:** run `"+history[0].ID+"`") {
+		t.Errorf("Expected cancellation note, got %v", comments)
+	}
+}
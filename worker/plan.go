@@ -0,0 +1,478 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// planStepPattern matches a Markdown checklist item such as "- [ ] Add the parser"
+var planStepPattern = regexp.MustCompile(`^(\s*[-*] \[)([ xX])(\] )(.+)$`)
+
+// planHeadingPattern matches a step heading such as "### Step 2: Add the parser - DONE ✅"
+var planHeadingPattern = regexp.MustCompile(`^#{2,6} Step \d+[:.]?\s+(.+?)(\s+-\s+DONE\b.*)?$`)
+
+// PlanStep is one step of an implementation plan, either a checklist item or a step heading
+type PlanStep struct {
+	Text    string
+	Done    bool
+	line    int  // index of the line in the status file
+	heading bool // whether the step is a heading marked done with "DONE"
+}
+
+// DefaultPlanMaxSteps is the number of plan steps kratt executes per run
+// unless configured otherwise
+const DefaultPlanMaxSteps = 20
+
+// PlanBudget limits how much of a plan is executed in one run
+type PlanBudget struct {
+	MaxSteps    int           // Zero means no limit
+	MaxDuration time.Duration // Zero means no limit; checked before each step
+}
+
+// exhausted reports whether the budget does not allow another step
+func (b PlanBudget) exhausted(completed int, elapsed time.Duration) bool {
+	return b.MaxSteps > 0 && completed >= b.MaxSteps || b.MaxDuration > 0 && elapsed >= b.MaxDuration
+}
+
+// implementationStatusPath returns the status file that holds the plan for a branch
+func implementationStatusPath(branch string) string {
+	return fmt.Sprintf("docs/%s-implementation-status.md", branch)
+}
+
+// parsePlan returns the checklist steps of an implementation status file
+func parsePlan(content string) []PlanStep {
+	var steps []PlanStep
+	for i, line := range strings.Split(content, "\n") {
+		if m := planStepPattern.FindStringSubmatch(line); m != nil {
+			steps = append(steps, PlanStep{Text: strings.TrimSpace(m[4]), Done: m[2] != " ", line: i})
+		} else if m := planHeadingPattern.FindStringSubmatch(line); m != nil {
+			steps = append(steps, PlanStep{Text: m[1], Done: m[2] != "", line: i, heading: true})
+		}
+	}
+	return steps
+}
+
+// markStepDone ticks off a step in the status file content
+func markStepDone(content string, step PlanStep) string {
+	lines := strings.Split(content, "\n")
+	if step.heading {
+		lines[step.line] += " - DONE ✅"
+	} else {
+		lines[step.line] = planStepPattern.ReplaceAllString(lines[step.line], "${1}x${3}${4}")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PlanPR runs the planning phase on a pull request: the agent writes an
+// implementation plan to the branch's status file, which is pushed and posted
+// to the PR. The run then awaits approval; approving it executes the plan.
+func (w *Worker) PlanPR(ctx context.Context, prNumber int) error {
+	if w.Runs == nil {
+		return fmt.Errorf("plan mode requires run history to resume the run")
+	}
+
+	run, err := w.startRun(prNumber)
+	if err != nil {
+		return err
+	}
+	run.TaskType = TaskPlan
+	return w.finishRun(ctx, run, w.planPR(ctx, run, prNumber))
+}
+
+// planPR runs the steps of PlanPR, filling in the run record
+func (w *Worker) planPR(ctx context.Context, run *RunRecord, prNumber int) error {
+	var prInfo string
+	err := w.runPhase(ctx, PhaseSetup, func(ctx context.Context) error {
+		var err error
+		prInfo, err = w.GitHub.GetPRInfo(ctx, prNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get PR info: %w", err)
+		}
+
+		run.Branch, err = w.extractBranchFromPRInfo(prInfo)
+		if err != nil {
+			return fmt.Errorf("failed to extract branch from PR info: %w", err)
+		}
+
+		return w.enterWorktree(ctx, run)
+	})
+	if err != nil {
+		return err
+	}
+	run.PlanFile = implementationStatusPath(run.Branch)
+
+	chain, route, err := w.selectAgents(parsePRDetails(prInfo))
+	if err != nil {
+		return fmt.Errorf("failed to select agent: %w", err)
+	}
+	run.Route = route
+
+	run.BaseSHA, err = w.Git.HeadSHA(ctx)
+	if err != nil {
+		return err
+	}
+
+	prompt := w.generatePrompt(prInfo) + "\n\n" + planInstructions(run.PlanFile)
+	err = w.runPhase(ctx, PhaseAgent, func(ctx context.Context) error {
+		_, err := w.runAgentChain(ctx, run, chain, prompt)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run agent: %w", err)
+	}
+
+	content, err := w.Git.ReadFile(ctx, run.PlanFile)
+	if err != nil {
+		return fmt.Errorf("agent did not write a plan: %w", err)
+	}
+	steps := parsePlan(content)
+	if len(steps) == 0 {
+		return fmt.Errorf("plan %s contains no checklist steps", run.PlanFile)
+	}
+
+	// The planning agent could change more than the plan, so its changes are
+	// checked like any other agent's before they are pushed
+	commits, violations, findings, err := w.checkAgentWork(ctx, run.BaseSHA)
+	if err != nil {
+		return err
+	}
+	run.AgentCommits = commits
+	run.SecretFindings = findings
+	if err := changesError(violations, findings); err != nil {
+		commentBody := fmt.Sprintf("## Kratt Implementation Plan\n\nThe plan in `%s` was not pushed.\n", run.PlanFile)
+		if len(violations) > 0 {
+			commentBody += "\n" + formatPolicyViolations(violations)
+		}
+		if len(findings) > 0 {
+			commentBody += "\n" + formatSecretFindings(findings)
+		}
+		if commentErr := w.GitHub.PostComment(ctx, prNumber, commentBody); commentErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to post comment: %w", commentErr))
+		}
+		return err
+	}
+
+	message, err := w.planCommitMessage(run, 0, fmt.Sprintf("docs: add implementation plan\n\nThe plan for this pull request is in %s.", run.PlanFile))
+	if err != nil {
+		return err
+	}
+	err = w.runPhase(ctx, PhasePush, func(ctx context.Context) error {
+		if err := w.pushAgentWork(ctx, run.BaseSHA, commits, message); err != nil {
+			return err
+		}
+		run.CommitSHA, err = w.Git.HeadSHA(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit and push plan: %w", err)
+	}
+
+	run.Status = RunStatusAwaitingApproval
+	run.ApprovalRequestedAt = time.Now()
+	if err := w.saveRun(run); err != nil {
+		return err
+	}
+	return w.GitHub.PostComment(ctx, prNumber, formatPlanComment(run, steps))
+}
+
+// decidePlan executes an approved plan or records its rejection
+func (w *Worker) decidePlan(ctx context.Context, run *RunRecord, approve bool, reviewer string) error {
+	run.ReviewedBy = reviewer
+	if !approve {
+		run.Status = RunStatusRejected
+		run.FinishedAt = time.Now()
+		if err := w.saveRun(run); err != nil {
+			return err
+		}
+		return w.GitHub.PostComment(ctx, run.PRNumber, formatApprovalDecisionComment(run))
+	}
+
+	run.Status = RunStatusRunning
+	if err := w.saveRun(run); err != nil {
+		return err
+	}
+	if err := w.GitHub.PostComment(ctx, run.PRNumber, formatApprovalDecisionComment(run)); err != nil {
+		return err
+	}
+	return w.finishRun(ctx, run, w.executePlan(ctx, run))
+}
+
+// ImplementPR works through the open steps of the PR branch's implementation
+// status file, running the agent once per step and committing each step
+// separately, until all steps are done or the worker's Budget is used up
+func (w *Worker) ImplementPR(ctx context.Context, prNumber int) error {
+	run, err := w.startRun(prNumber)
+	if err != nil {
+		return err
+	}
+	run.TaskType = TaskImplement
+	return w.finishRun(ctx, run, w.implementPR(ctx, run, prNumber))
+}
+
+// implementPR enters the PR's worktree and executes its status file
+func (w *Worker) implementPR(ctx context.Context, run *RunRecord, prNumber int) error {
+	err := w.runPhase(ctx, PhaseSetup, func(ctx context.Context) error {
+		prInfo, err := w.GitHub.GetPRInfo(ctx, prNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get PR info: %w", err)
+		}
+
+		run.Branch, err = w.extractBranchFromPRInfo(prInfo)
+		if err != nil {
+			return fmt.Errorf("failed to extract branch from PR info: %w", err)
+		}
+
+		return w.enterWorktree(ctx, run)
+	})
+	if err != nil {
+		return err
+	}
+	run.PlanFile = implementationStatusPath(run.Branch)
+
+	content, err := w.Git.ReadFile(ctx, run.PlanFile)
+	if err != nil {
+		return fmt.Errorf("failed to read status file: %w", err)
+	}
+	if len(parsePlan(content)) == 0 {
+		return fmt.Errorf("status file %s contains no steps", run.PlanFile)
+	}
+	return w.executePlan(ctx, run)
+}
+
+// executePlan runs the agent once per open step of the plan until all steps
+// are done or the worker's Budget is used up. After each step the worker runs
+// lint and tests, ticks the step off in the status file if they pass, commits,
+// pushes and reports progress on the PR. A step that fails verification, or
+// that the agent removed from the plan, stops the run without being pushed.
+func (w *Worker) executePlan(ctx context.Context, run *RunRecord) error {
+	prInfo, err := w.GitHub.GetPRInfo(ctx, run.PRNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get PR info: %w", err)
+	}
+	chain, route, err := w.selectAgents(parsePRDetails(prInfo))
+	if err != nil {
+		return fmt.Errorf("failed to select agent: %w", err)
+	}
+	run.Route = route
+
+	started := time.Now()
+	for {
+		content, err := w.Git.ReadFile(ctx, run.PlanFile)
+		if err != nil {
+			return fmt.Errorf("failed to read plan: %w", err)
+		}
+		steps := parsePlan(content)
+		index := nextPlanStep(steps)
+		if index < 0 {
+			return nil
+		}
+		if w.Budget.exhausted(run.StepsCompleted, time.Since(started)) {
+			return w.GitHub.PostComment(ctx, run.PRNumber, formatBudgetComment(run, steps))
+		}
+		step := steps[index]
+
+		stepBase, err := w.Git.HeadSHA(ctx)
+		if err != nil {
+			return err
+		}
+
+		prompt := w.generatePrompt(prInfo) + "\n\n" + planStepInstructions(run.PlanFile, index+1, step)
+		var agentResult *AgentResult
+		err = w.runPhase(ctx, PhaseAgent, func(ctx context.Context) error {
+			var err error
+			agentResult, err = w.runAgentChain(ctx, run, chain, prompt)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to run agent for step %d: %w", index+1, err)
+		}
+
+		lintOutput, lintErr := w.runCheck(ctx, PhaseLint, w.LintCommand)
+		testOutput, testErr := w.runCheck(ctx, PhaseTest, w.TestCommand)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		verified := lintErr == nil && testErr == nil
+
+		// Re-read the plan: the agent may have edited or reordered it while
+		// working on the step, so the step is found again by its text
+		content, err = w.Git.ReadFile(ctx, run.PlanFile)
+		if err != nil {
+			return fmt.Errorf("failed to read plan: %w", err)
+		}
+		steps = parsePlan(content)
+		current := findPlanStep(steps, step.Text)
+		if verified && current >= 0 && !steps[current].Done {
+			content = markStepDone(content, steps[current])
+			if err := w.Git.WriteFile(ctx, run.PlanFile, content); err != nil {
+				return fmt.Errorf("failed to update plan: %w", err)
+			}
+			steps[current].Done = true
+		}
+
+		var stepErr error
+		var failure string
+		switch {
+		case !verified:
+			stepErr = fmt.Errorf("step %d failed verification: %w", index+1, errors.Join(lintErr, testErr))
+			failure = "failed lint or tests"
+		case current < 0:
+			// Executing the plan again would attempt the same step forever
+			stepErr = fmt.Errorf("step %d %q is no longer in plan %s", index+1, step.Text, run.PlanFile)
+			failure = "is no longer in the plan"
+		}
+		progress := formatPlanProgressComment(index+1, step, steps, failure) + "\n" +
+			w.formatResultsComment(run, agentResult, lintOutput, lintErr, testOutput, testErr)
+
+		// Changes of a step that was not completed stay in the worktree
+		// unpushed, for inspection
+		if stepErr != nil {
+			if err := w.GitHub.PostComment(ctx, run.PRNumber, progress); err != nil {
+				return errors.Join(stepErr, fmt.Errorf("failed to post comment: %w", err))
+			}
+			return stepErr
+		}
+
+		commits, violations, findings, err := w.checkAgentWork(ctx, stepBase)
+		if err != nil {
+			return err
+		}
+		run.AgentCommits = append(run.AgentCommits, commits...)
+		run.SecretFindings = append(run.SecretFindings, findings...)
+
+		if len(violations) > 0 {
+			progress += "\n" + formatPolicyViolations(violations)
+		}
+		if len(findings) > 0 {
+			progress += "\n" + formatSecretFindings(findings)
+		}
+		if err := w.GitHub.PostComment(ctx, run.PRNumber, progress); err != nil {
+			return fmt.Errorf("failed to post comment: %w", err)
+		}
+		if err := changesError(violations, findings); err != nil {
+			return err
+		}
+
+		summary := step.Text
+		if agentResult != nil && strings.TrimSpace(agentResult.Summary) != "" {
+			summary += "\n\n" + agentResult.Summary
+		}
+		message, err := w.planCommitMessage(run, index+1, summary)
+		if err != nil {
+			return err
+		}
+		err = w.runPhase(ctx, PhasePush, func(ctx context.Context) error {
+			return w.pushAgentWork(ctx, stepBase, commits, message)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to commit and push step %d: %w", index+1, err)
+		}
+
+		run.StepsCompleted++
+		if err := w.saveRun(run); err != nil {
+			return err
+		}
+	}
+}
+
+// findPlanStep returns the index of the step with the given text, preferring
+// an open one, or -1 if the plan no longer contains it
+func findPlanStep(steps []PlanStep, text string) int {
+	found := -1
+	for i, step := range steps {
+		if step.Text != text {
+			continue
+		}
+		if !step.Done {
+			return i
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	return found
+}
+
+// nextPlanStep returns the index of the first open step, or -1 if all are done
+func nextPlanStep(steps []PlanStep) int {
+	for i, step := range steps {
+		if !step.Done {
+			return i
+		}
+	}
+	return -1
+}
+
+// planInstructions asks the agent to write an implementation plan without implementing it
+func planInstructions(path string) string {
+	return fmt.Sprintf(`Do not implement anything yet. Study the pull request and write an implementation plan to %s.
+List the implementation steps as a Markdown checklist, one "- [ ] <step>" line per step, in the order they should be done.
+Each step should be small enough to implement and test on its own. Do not change any other files.`, path)
+}
+
+// planStepInstructions asks the agent to implement a single step of the plan
+func planStepInstructions(path string, number int, step PlanStep) string {
+	return fmt.Sprintf(`The implementation plan is in %s. Implement only step %d:
+
+%s
+
+Do not work on later steps. The step will be ticked off in the plan when you are done.`, path, number, step.Text)
+}
+
+// formatPlanComment posts the plan for review
+func formatPlanComment(run *RunRecord, steps []PlanStep) string {
+	var comment strings.Builder
+	comment.WriteString("## Kratt Implementation Plan\n\n")
+	fmt.Fprintf(&comment, "Run `%s` wrote the plan to `%s`:\n\n", run.ID, run.PlanFile)
+	comment.WriteString(formatPlanSteps(steps))
+	fmt.Fprintf(&comment, "\nReply `%s` to execute the plan step by step or `%s` to discard it, or run `kratt approve %s` locally.\n",
+		ApproveCommand, RejectCommand, run.ID)
+	return comment.String()
+}
+
+// formatPlanProgressComment reports the outcome of a plan step; failure says
+// why the step was not completed and is empty if it was
+func formatPlanProgressComment(number int, step PlanStep, steps []PlanStep, failure string) string {
+	done := 0
+	for _, s := range steps {
+		if s.Done {
+			done++
+		}
+	}
+
+	var comment strings.Builder
+	fmt.Fprintf(&comment, "## Kratt Plan Progress: step %d of %d\n\n", number, len(steps))
+	if failure == "" {
+		fmt.Fprintf(&comment, "Completed **%s** (%d/%d steps done).\n\n", step.Text, done, len(steps))
+	} else {
+		fmt.Fprintf(&comment, "❌ **%s** %s; stopping without pushing (%d/%d steps done).\n\n", step.Text, failure, done, len(steps))
+	}
+	comment.WriteString(formatPlanSteps(steps))
+	return comment.String()
+}
+
+// formatBudgetComment reports that the run stopped before finishing the plan
+func formatBudgetComment(run *RunRecord, steps []PlanStep) string {
+	var comment strings.Builder
+	comment.WriteString("## Kratt Plan Progress: budget reached\n\n")
+	fmt.Fprintf(&comment, "Run `%s` completed %d steps and stopped at its budget. Run it again to continue with the open steps:\n\n", run.ID, run.StepsCompleted)
+	comment.WriteString(formatPlanSteps(steps))
+	return comment.String()
+}
+
+// formatPlanSteps renders plan steps as a Markdown checklist
+func formatPlanSteps(steps []PlanStep) string {
+	var b strings.Builder
+	for _, step := range steps {
+		mark := " "
+		if step.Done {
+			mark = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s\n", mark, step.Text)
+	}
+	return b.String()
+}
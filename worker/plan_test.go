@@ -0,0 +1,230 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePlan(t *testing.T) {
+	content := "# Plan\n\n- [x] Add parser\n- [ ] Add lexer\n  * [ ] Handle comments\nNotes\n"
+	steps := parsePlan(content)
+	if len(steps) != 3 || !steps[0].Done || steps[1].Done || steps[2].Text != "Handle comments" {
+		t.Fatalf("Unexpected steps: %+v", steps)
+	}
+
+	updated := markStepDone(content, steps[2])
+	if !strings.Contains(updated, "  * [x] Handle comments") || !strings.Contains(updated, "- [ ] Add lexer") {
+		t.Errorf("Unexpected updated plan:\n%s", updated)
+	}
+}
+
+func TestWorkerPlanAndExecute(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	planFile := "docs/feature-implementation-status.md"
+	fakeGit.WriteFile(context.Background(), planFile, "# Plan\n\n- [ ] Add parser\n- [ ] Add lexer\n")
+
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(12, `{"headRefName": "feature"}`)
+
+	agent := NewFakeAgent("agent")
+	runs := NewFakeRunStore()
+	w := &Worker{
+		LintCommand: []string{"go", "vet", "./..."},
+		TestCommand: []string{"go", "test", "./..."},
+		Deadline:    5 * time.Second,
+		Agent:       agent,
+		Runs:        runs,
+		Git:         fakeGit,
+		GitHub:      fakeGitHub,
+		Runner:      NewFakeCommandRunner(),
+	}
+
+	if err := w.PlanPR(context.Background(), 12); err != nil {
+		t.Fatalf("PlanPR failed: %v", err)
+	}
+
+	history, _ := runs.ListRuns()
+	run := history[0]
+	if run.Status != RunStatusAwaitingApproval || run.PlanFile != planFile || run.TaskType != TaskPlan {
+		t.Fatalf("Unexpected run record: %+v", run)
+	}
+	if prompt := agent.GetPrompts()[0]; !strings.Contains(prompt, "Do not implement anything yet") {
+		t.Errorf("Expected planning prompt, got:\n%s", prompt)
+	}
+	if comment := fakeGitHub.GetComments(12)[0]; !strings.Contains(comment, "- [ ] Add parser") || !strings.Contains(comment, "/kratt approve") {
+		t.Errorf("Expected plan comment, got:\n%s", comment)
+	}
+
+	if err := w.ApproveRun(context.Background(), run.ID, "maintainer"); err != nil {
+		t.Fatalf("ApproveRun failed: %v", err)
+	}
+
+	prompts := agent.GetPrompts()
+	if len(prompts) != 3 || !strings.Contains(prompts[1], "Implement only step 1:\n\nAdd parser") || !strings.Contains(prompts[2], "step 2:\n\nAdd lexer") {
+		t.Errorf("Expected one prompt per step, got %d prompts", len(prompts))
+	}
+
+	commits := fakeGit.GetCommits()
+	if len(commits) != 3 || !strings.HasPrefix(commits[0], "docs: add implementation plan\n") ||
+		!strings.HasPrefix(commits[1], "feat: add parser\n") || !strings.Contains(commits[1], "Kratt-Step: 1\nKratt-Agent: agent\nRefs: #12\n") ||
+		!strings.HasPrefix(commits[2], "feat: add lexer\n") || !strings.Contains(commits[2], "Kratt-Step: 2\n") {
+		t.Errorf("Expected one commit per step, got %v", commits)
+	}
+	if plan := fakeGit.GetWrittenFiles()[planFile]; !strings.Contains(plan, "- [x] Add parser\n- [x] Add lexer") {
+		t.Errorf("Expected all steps to be ticked off, got:\n%s", plan)
+	}
+
+	comments := fakeGitHub.GetComments(12)
+	if len(comments) != 4 || !strings.Contains(comments[3], "step 2 of 2") || !strings.Contains(comments[3], "(2/2 steps done)") {
+		t.Errorf("Expected a progress comment per step, got %v", comments)
+	}
+
+	run, _ = runs.GetRun(run.ID)
+	if run.Status != RunStatusSucceeded {
+		t.Errorf("Expected plan execution to succeed, got %+v", run)
+	}
+}
+
+func TestWorkerPlanChecksChanges(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.WriteFile(context.Background(), "docs/feature-implementation-status.md", "- [ ] Add parser\n")
+	fakeGit.SetStagedDiff(secretScanTestDiff)
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(12, `{"headRefName": "feature"}`)
+	w := &Worker{
+		Deadline:      5 * time.Second,
+		Agent:         NewFakeAgent("agent"),
+		SecretScanner: &SecretScanner{},
+		Runs:          NewFakeRunStore(),
+		Git:           fakeGit,
+		GitHub:        fakeGitHub,
+		Runner:        NewFakeCommandRunner(),
+	}
+
+	err := w.PlanPR(context.Background(), 12)
+	var secretsErr *SecretsFoundError
+	if !errors.As(err, &secretsErr) {
+		t.Fatalf("Expected secrets error, got %v", err)
+	}
+	if fakeGit.GetPushCount() != 0 {
+		t.Error("Expected the plan not to be pushed")
+	}
+	if comments := fakeGitHub.GetComments(12); len(comments) != 1 || !strings.Contains(comments[0], "was not pushed") {
+		t.Errorf("Expected a comment explaining the blocked plan, got %v", comments)
+	}
+}
+
+func TestParsePlanHeadings(t *testing.T) {
+	content := "# Status\n\n### Step 1: Add parser - DONE ✅\n\n### Step 2: Add lexer\n"
+	steps := parsePlan(content)
+	if len(steps) != 2 || !steps[0].Done || steps[1].Done || steps[1].Text != "Add lexer" {
+		t.Fatalf("Unexpected steps: %+v", steps)
+	}
+
+	updated := markStepDone(content, steps[1])
+	if !strings.Contains(updated, "### Step 2: Add lexer - DONE ✅") {
+		t.Errorf("Unexpected updated plan:\n%s", updated)
+	}
+}
+
+func TestWorkerImplementPRBudget(t *testing.T) {
+	fakeGit, fakeGitHub := NewFakeLocalGit(), NewFakeGitHub()
+	fakeGit.WriteFile(context.Background(), "docs/feature-implementation-status.md", "### Step 1: Add parser - DONE ✅\n### Step 2: Add lexer\n### Step 3: Add printer\n")
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+	w.Budget = PlanBudget{MaxSteps: 1}
+
+	if err := w.ImplementPR(context.Background(), 7); err != nil {
+		t.Fatalf("ImplementPR failed: %v", err)
+	}
+
+	if commits := fakeGit.GetCommits(); len(commits) != 1 || !strings.HasPrefix(commits[0], "feat: add lexer\n") || !strings.Contains(commits[0], "Kratt-Step: 2\n") {
+		t.Errorf("Expected one commit for step 2, got %v", commits)
+	}
+	comments := fakeGitHub.GetComments(7)
+	if len(comments) != 2 || !strings.Contains(comments[1], "budget reached") {
+		t.Errorf("Expected a budget comment, got %v", comments)
+	}
+
+	history, _ := w.Runs.ListRuns()
+	if run := history[0]; run.Status != RunStatusSucceeded || run.StepsCompleted != 1 || run.TaskType != TaskImplement {
+		t.Errorf("Unexpected run record: %+v", run)
+	}
+}
+
+func TestWorkerImplementPRStopsOnFailedVerification(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.WriteFile(context.Background(), "docs/feature-implementation-status.md", "- [ ] Add parser\n- [ ] Add lexer\n")
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature")
+	w.Runner.(*FakeCommandRunner).SetResponse("go test ./...", []byte("FAIL"), errors.New("exit status 1"))
+
+	err := w.ImplementPR(context.Background(), 7)
+	if err == nil || !strings.Contains(err.Error(), "step 1 failed verification") {
+		t.Fatalf("Expected verification failure, got %v", err)
+	}
+	if len(fakeGit.GetCommits()) != 0 || fakeGit.GetPushCount() != 0 {
+		t.Errorf("Expected the failed step not to be committed, got %v", fakeGit.GetCommits())
+	}
+	if plan := fakeGit.GetWrittenFiles()["docs/feature-implementation-status.md"]; !strings.Contains(plan, "- [ ] Add parser") {
+		t.Errorf("Expected the failed step to stay open, got:\n%s", plan)
+	}
+}
+
+// planEditingAgent is an agent that rewrites the plan while working on a step
+type planEditingAgent struct {
+	git     *FakeLocalGit
+	path    string
+	content string
+}
+
+func (a *planEditingAgent) Name() string { return "plan-editing" }
+
+func (a *planEditingAgent) Run(ctx context.Context, runner CommandRunner, prompt string) (*AgentResult, error) {
+	return nil, a.git.WriteFile(ctx, a.path, a.content)
+}
+
+func TestWorkerImplementPRMatchesStepsByText(t *testing.T) {
+	const planFile = "docs/feature-implementation-status.md"
+	fakeGit := NewFakeLocalGit()
+	fakeGit.WriteFile(context.Background(), planFile, "- [ ] Add parser\n- [ ] Add lexer\n")
+	agent := &planEditingAgent{git: fakeGit, path: planFile, content: "- [ ] Add lexer\n- [ ] Add parser\n"}
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature", func(w *Worker) { w.Agent = agent })
+	w.Budget = PlanBudget{MaxSteps: 1}
+
+	if err := w.ImplementPR(context.Background(), 7); err != nil {
+		t.Fatalf("ImplementPR failed: %v", err)
+	}
+	if plan := fakeGit.GetWrittenFiles()[planFile]; plan != "- [ ] Add lexer\n- [x] Add parser\n" {
+		t.Errorf("Expected the reordered step to be ticked off, got:\n%s", plan)
+	}
+
+	agent.content = "- [ ] Add tokenizer\n- [x] Add parser\n"
+	fakeGit.WriteFile(context.Background(), planFile, "- [ ] Add lexer\n- [x] Add parser\n")
+	err := w.ImplementPR(context.Background(), 7)
+	if err == nil || !strings.Contains(err.Error(), "no longer in plan") {
+		t.Fatalf("Expected a step removed by the agent to stop the run, got %v", err)
+	}
+	if commits := fakeGit.GetCommits(); len(commits) != 1 || !strings.Contains(commits[0], "add parser") {
+		t.Errorf("Expected only the completed step to be committed, got %v", commits)
+	}
+}
+
+func TestWorkerImplementPRChecksAgentCommits(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.WriteFile(context.Background(), "docs/feature-implementation-status.md", "- [ ] Add parser\n")
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature")
+	fakeGit.SetAgentCommits(testAgentCommits)
+	w.Policy = &Policy{CommitMessagePattern: regexp.MustCompile(`^(feat|fix): `)}
+
+	err := w.ImplementPR(context.Background(), 7)
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) != 1 || policyErr.Violations[0].Rule != "commit-message" {
+		t.Fatalf("Expected the step's commit message to be rejected, got %v", err)
+	}
+	if fakeGit.GetPushCount() != 0 {
+		t.Error("Expected nothing to be pushed")
+	}
+}
@@ -0,0 +1,301 @@
+package worker
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Policy rule names reported in violations
+const (
+	PolicyProtectedPath     = "protected-path"
+	PolicyMaxFiles          = "max-files"
+	PolicyMaxLines          = "max-lines"
+	PolicyForbiddenDeletion = "forbidden-deletion"
+	PolicyTestSkip          = "test-skip"
+	PolicyBinaryFile        = "binary-file"
+	PolicyReplaceDirective  = "replace-directive"
+	PolicyCommitMessage     = "commit-message"
+)
+
+// Policy restricts which changes the worker may commit. It is evaluated on
+// the staged diff before committing; any violation prevents the commit.
+type Policy struct {
+	ProtectedPaths          []string // Globs of paths that must not change, e.g. ".github/workflows/**"
+	MaxFiles                int      // Maximum number of changed files; zero means unlimited
+	MaxLines                int      // Maximum number of changed lines; zero means unlimited
+	ForbiddenDeletions      []string // Globs of paths that must not be deleted, e.g. "**/*_test.go"
+	ForbidTestSkips         bool     // Reject newly added t.Skip calls in Go tests
+	ForbidBinary            bool     // Reject added or modified binary files
+	ForbidReplaceDirectives bool     // Reject new replace directives in go.mod
+
+	// CommitMessagePattern must match the subject of every commit the agent
+	// created itself; nil accepts any message
+	CommitMessagePattern *regexp.Regexp
+}
+
+// DefaultPolicy returns the policy used by the CLI when none is configured
+func DefaultPolicy() *Policy {
+	return &Policy{
+		ProtectedPaths:          []string{".github/workflows/**"},
+		ForbiddenDeletions:      []string{"**/*_test.go"},
+		ForbidTestSkips:         true,
+		ForbidBinary:            true,
+		ForbidReplaceDirectives: true,
+	}
+}
+
+// PolicyViolation describes one change that the policy does not allow
+type PolicyViolation struct {
+	Rule    string
+	Path    string
+	Message string
+}
+
+// PolicyError is returned when the staged changes violate the policy
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	rules := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		rules[i] = v.Rule
+		if v.Path != "" {
+			rules[i] += " (" + v.Path + ")"
+		}
+	}
+	return "changes violate policy: " + strings.Join(rules, ", ")
+}
+
+// FileChange describes the changes to one file in a diff
+type FileChange struct {
+	Path       string
+	OldPath    string // Path before a rename; equal to Path otherwise
+	Added      bool
+	Deleted    bool
+	Binary     bool
+	Additions  int
+	Deletions  int
+	AddedLines []DiffLine
+}
+
+// DiffLine is a line added by a diff, numbered in the new version of the file
+type DiffLine struct {
+	Number int
+	Text   string
+}
+
+var testSkipPattern = regexp.MustCompile(`\bt\.(Skip|SkipNow|Skipf)\(`)
+
+// Evaluate checks a unified diff against the policy and returns all violations
+func (p *Policy) Evaluate(diff string) []PolicyViolation {
+	changes := parseUnifiedDiff(diff)
+	var violations []PolicyViolation
+
+	var lines int
+	for _, change := range changes {
+		lines += change.Additions + change.Deletions
+
+		for _, glob := range p.ProtectedPaths {
+			if matchGlob(glob, change.Path) || matchGlob(glob, change.OldPath) {
+				violations = append(violations, PolicyViolation{PolicyProtectedPath, change.Path, fmt.Sprintf("matches protected path `%s`", glob)})
+				break
+			}
+		}
+
+		if change.Deleted {
+			for _, glob := range p.ForbiddenDeletions {
+				if matchGlob(glob, change.Path) {
+					violations = append(violations, PolicyViolation{PolicyForbiddenDeletion, change.Path, fmt.Sprintf("deleting files matching `%s` is not allowed", glob)})
+					break
+				}
+			}
+		}
+
+		if p.ForbidBinary && change.Binary && !change.Deleted {
+			violations = append(violations, PolicyViolation{PolicyBinaryFile, change.Path, "binary files are not allowed"})
+		}
+
+		for _, line := range change.AddedLines {
+			if p.ForbidTestSkips && strings.HasSuffix(change.Path, "_test.go") && testSkipPattern.MatchString(line.Text) {
+				violations = append(violations, PolicyViolation{PolicyTestSkip, change.Path, fmt.Sprintf("adds a skipped test: `%s`", strings.TrimSpace(line.Text))})
+			}
+			if p.ForbidReplaceDirectives && isGoMod(change.Path) && isReplaceDirective(line.Text) {
+				violations = append(violations, PolicyViolation{PolicyReplaceDirective, change.Path, fmt.Sprintf("adds a replace directive: `%s`", strings.TrimSpace(line.Text))})
+			}
+		}
+	}
+
+	if p.MaxFiles > 0 && len(changes) > p.MaxFiles {
+		violations = append(violations, PolicyViolation{PolicyMaxFiles, "", fmt.Sprintf("changes %d files, at most %d allowed", len(changes), p.MaxFiles)})
+	}
+	if p.MaxLines > 0 && lines > p.MaxLines {
+		violations = append(violations, PolicyViolation{PolicyMaxLines, "", fmt.Sprintf("changes %d lines, at most %d allowed", lines, p.MaxLines)})
+	}
+
+	return violations
+}
+
+// EvaluateCommits checks the messages of the agent's own commits against the policy
+func (p *Policy) EvaluateCommits(commits []CommitInfo) []PolicyViolation {
+	var violations []PolicyViolation
+	if p.CommitMessagePattern == nil {
+		return violations
+	}
+	for _, commit := range commits {
+		if !p.CommitMessagePattern.MatchString(commit.Subject) {
+			violations = append(violations, PolicyViolation{PolicyCommitMessage, shortSHA(commit.SHA), fmt.Sprintf("commit message `%s` does not match `%s`", commit.Subject, p.CommitMessagePattern)})
+		}
+	}
+	return violations
+}
+
+// isGoMod reports whether path is a go.mod file
+func isGoMod(path string) bool {
+	return path == "go.mod" || strings.HasSuffix(path, "/go.mod")
+}
+
+// isReplaceDirective reports whether a go.mod line starts or belongs to a replace directive
+func isReplaceDirective(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "replace ") || strings.HasPrefix(line, "replace(") || strings.Contains(line, "=>")
+}
+
+// parseUnifiedDiff parses the output of git diff into per-file changes
+func parseUnifiedDiff(diff string) []FileChange {
+	var changes []FileChange
+	var current *FileChange
+	inHunk := false
+	lineNumber := 0
+
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			changes = append(changes, FileChange{})
+			current = &changes[len(changes)-1]
+			current.OldPath, current.Path = parseDiffGitLine(line)
+			inHunk = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if strings.HasPrefix(line, "@@") {
+			inHunk = true
+			lineNumber = parseHunkStart(line)
+			continue
+		}
+
+		if inHunk {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				current.Additions++
+				current.AddedLines = append(current.AddedLines, DiffLine{Number: lineNumber, Text: line[1:]})
+				lineNumber++
+			case strings.HasPrefix(line, "-"):
+				current.Deletions++
+			case strings.HasPrefix(line, " "):
+				lineNumber++
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "new file mode"):
+			current.Added = true
+		case strings.HasPrefix(line, "deleted file mode"):
+			current.Deleted = true
+		case strings.HasPrefix(line, "rename from "):
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			current.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- a/"):
+			current.OldPath = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "+++ b/"):
+			current.Path = strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			current.Binary = true
+		}
+	}
+
+	for i := range changes {
+		if changes[i].Deleted {
+			changes[i].Path = changes[i].OldPath
+		}
+	}
+	return changes
+}
+
+// parseHunkStart returns the first new-file line number of a "@@ -a,b +c,d @@" hunk header
+func parseHunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(fields[2][1:], ",")
+	number, _ := strconv.Atoi(start)
+	return number
+}
+
+// parseDiffGitLine extracts the old and new paths from a "diff --git a/x b/y" line
+func parseDiffGitLine(line string) (oldPath, newPath string) {
+	paths := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.Index(paths, " b/"); i >= 0 && strings.HasPrefix(paths, "a/") {
+		return paths[2:i], paths[i+3:]
+	}
+	return paths, paths
+}
+
+// matchGlob reports whether path matches a glob where * matches within a
+// path segment and ** matches across segments. Patterns without a slash
+// match the file name in any directory.
+func matchGlob(glob, path string) bool {
+	if path == "" {
+		return false
+	}
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	return globRegexp(glob).MatchString(path)
+}
+
+// globRegexp translates a glob into an anchored regular expression
+func globRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// formatPolicyViolations renders policy violations for the PR comment
+func formatPolicyViolations(violations []PolicyViolation) string {
+	var b strings.Builder
+	b.WriteString("### Policy\n\n")
+	b.WriteString("🚫 **Changes were not committed** because they violate the repository policy:\n\n")
+	for _, v := range violations {
+		if v.Path != "" {
+			fmt.Fprintf(&b, "- `%s` (%s): %s\n", v.Path, v.Rule, v.Message)
+		} else {
+			fmt.Fprintf(&b, "- %s: %s\n", v.Rule, v.Message)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const policyTestDiff = `diff --git a/.github/workflows/ci.yml b/.github/workflows/ci.yml
+index 1111111..2222222 100644
+--- a/.github/workflows/ci.yml
++++ b/.github/workflows/ci.yml
+@@ -1,2 +1,2 @@
+-on: push
++on: workflow_dispatch
+diff --git a/parser/parser_test.go b/parser/parser_test.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/parser/parser_test.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package parser
+-
+-func TestParse(t *testing.T) {}
+diff --git a/lexer/lexer_test.go b/lexer/lexer_test.go
+index 4444444..5555555 100644
+--- a/lexer/lexer_test.go
++++ b/lexer/lexer_test.go
+@@ -10,3 +10,4 @@ func TestLex(t *testing.T) {
+ 	input := "a"
++	t.Skip("flaky")
+ 	lex(input)
+diff --git a/go.mod b/go.mod
+index 6666666..7777777 100644
+--- a/go.mod
++++ b/go.mod
+@@ -3,3 +3,5 @@ module example.com/app
+ go 1.22
++
++replace example.com/lib => ../lib
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..8888888
+Binary files /dev/null and b/logo.png differ
+diff --git a/old_test.go b/new_test.go
+similarity index 100%
+rename from old_test.go
+rename to new_test.go
+`
+
+func TestPolicyEvaluate(t *testing.T) {
+	violations := DefaultPolicy().Evaluate(policyTestDiff)
+
+	expected := []string{
+		PolicyProtectedPath + " .github/workflows/ci.yml",
+		PolicyForbiddenDeletion + " parser/parser_test.go",
+		PolicyTestSkip + " lexer/lexer_test.go",
+		PolicyReplaceDirective + " go.mod",
+		PolicyBinaryFile + " logo.png",
+	}
+	if len(violations) != len(expected) {
+		t.Fatalf("Expected %d violations, got %+v", len(expected), violations)
+	}
+	for i, v := range violations {
+		if got := v.Rule + " " + v.Path; got != expected[i] {
+			t.Errorf("Violation %d = %q, want %q", i, got, expected[i])
+		}
+	}
+
+	limits := &Policy{MaxFiles: 5, MaxLines: 7}
+	violations = limits.Evaluate(policyTestDiff)
+	if len(violations) != 2 || violations[0].Rule != PolicyMaxFiles || violations[1].Rule != PolicyMaxLines {
+		t.Errorf("Expected file and line limit violations, got %+v", violations)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		{".github/workflows/**", ".github/workflows/ci.yml", true},
+		{".github/workflows/**", ".github/dependabot.yml", false},
+		{"**/*_test.go", "worker/worker_test.go", true},
+		{"**/*_test.go", "worker_test.go", true},
+		{"*_test.go", "deep/dir/x_test.go", true},
+		{"cmd/*.go", "cmd/sub/root.go", false},
+		{"go.mod", "tools/go.mod", true},
+		{"go.sum", "go.mod", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.glob, tt.path); got != tt.expected {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestWorkerProcessPRPolicyViolation(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetStagedDiff(policyTestDiff)
+
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(4, `{"headRefName": "feature"}`)
+
+	w := &Worker{
+		LintCommand: []string{"go", "vet", "./..."},
+		TestCommand: []string{"go", "test", "./..."},
+		Deadline:    5 * time.Second,
+		Agent:       NewFakeAgent("agent"),
+		Policy:      &Policy{ProtectedPaths: []string{".github/workflows/**"}},
+		Git:         fakeGit,
+		GitHub:      fakeGitHub,
+		Runner:      NewFakeCommandRunner(),
+	}
+
+	err := w.ProcessPR(context.Background(), 4)
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) != 1 {
+		t.Fatalf("Expected policy error with one violation, got %v", err)
+	}
+
+	if len(fakeGit.GetCommits()) != 0 {
+		t.Error("Expected changes violating the policy not to be committed")
+	}
+
+	comment := fakeGitHub.GetComments(4)[0]
+	if !strings.Contains(comment, "Changes were not committed") || !strings.Contains(comment, "`.github/workflows/ci.yml` (protected-path)") {
+		t.Errorf("Expected comment to explain the violation, got:\n%s", comment)
+	}
+}
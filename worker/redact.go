@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RedactedPlaceholder replaces secrets in text leaving the machine
+const RedactedPlaceholder = "[REDACTED]"
+
+// minSecretLength is the shortest environment value treated as a secret;
+// shorter values would mask ordinary words in the output
+const minSecretLength = 8
+
+// secretPattern is a known credential format
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// secretPatterns match common token formats regardless of where they came from.
+// The first capture group, if any, is kept when redacting.
+var secretPatterns = []secretPattern{
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)(aws_secret_access_key["']?\s*[=:]\s*["']?)[A-Za-z0-9/+=]{40}`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?:[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----)?`)},
+}
+
+// secretEnvMarkers identify environment variables whose values are secrets
+var secretEnvMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL", "AUTH"}
+
+// Redactor masks known secret values and common token patterns in text
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor creates a Redactor masking the given secret values in addition
+// to the built-in token patterns
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	// Replace longer secrets first so that a secret containing another is fully masked
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+// Redact returns text with all secrets replaced by RedactedPlaceholder
+func (r *Redactor) Redact(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, RedactedPlaceholder)
+	}
+	for _, pattern := range secretPatterns {
+		if pattern.re.NumSubexp() > 0 {
+			text = pattern.re.ReplaceAllString(text, "${1}"+RedactedPlaceholder)
+		} else {
+			text = pattern.re.ReplaceAllLiteralString(text, RedactedPlaceholder)
+		}
+	}
+	return text
+}
+
+// redactRun masks secrets in the error messages and agent output a run
+// record keeps, before it is written to run history
+func (r *Redactor) redactRun(run *RunRecord) {
+	run.Error = r.Redact(run.Error)
+	for i := range run.AgentAttempts {
+		run.AgentAttempts[i].Error = r.Redact(run.AgentAttempts[i].Error)
+	}
+	for i := range run.Comparison {
+		entry := &run.Comparison[i]
+		entry.AgentError = r.Redact(entry.AgentError)
+		entry.PushError = r.Redact(entry.PushError)
+		entry.Summary = r.Redact(entry.Summary)
+	}
+}
+
+// SecretEnvValues returns the values of environment variables whose names
+// suggest they hold credentials, e.g. GITHUB_TOKEN or AWS_SECRET_ACCESS_KEY
+func SecretEnvValues() []string {
+	var values []string
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		upper := strings.ToUpper(name)
+		for _, marker := range secretEnvMarkers {
+			if strings.Contains(upper, marker) {
+				values = append(values, value)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// DefaultEnvAllowlist lists the environment variables every command needs
+var DefaultEnvAllowlist = []string{"PATH", "HOME", "USER", "LANG", "TERM", "TMPDIR"}
+
+// filterEnv returns the entries of environ whose names are in allowed
+func filterEnv(environ []string, allowed []string) []string {
+	keep := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		keep[name] = true
+	}
+
+	filtered := []string{}
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if keep[name] {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// RedactingGitHub implements GitHub interface by redacting secrets from all
+// text before it is sent to the wrapped GitHub
+type RedactingGitHub struct {
+	GitHub   GitHub
+	Redactor *Redactor
+}
+
+// GetPRInfo retrieves pull request information from the wrapped GitHub
+func (g *RedactingGitHub) GetPRInfo(ctx context.Context, prNumber int) (string, error) {
+	return g.GitHub.GetPRInfo(ctx, prNumber)
+}
+
+// PostComment posts a redacted comment to the specified pull request
+func (g *RedactingGitHub) PostComment(ctx context.Context, prNumber int, body string) error {
+	return g.GitHub.PostComment(ctx, prNumber, g.Redactor.Redact(body))
+}
+
+// CreatePR creates a pull request with redacted title and description
+func (g *RedactingGitHub) CreatePR(ctx context.Context, title, description string, options PROptions) (PullRequest, error) {
+	return g.GitHub.CreatePR(ctx, g.Redactor.Redact(title), g.Redactor.Redact(description), options)
+}
+
+// GetIssue retrieves issue information from the wrapped GitHub
+func (g *RedactingGitHub) GetIssue(ctx context.Context, issueNumber int) (string, error) {
+	return g.GitHub.GetIssue(ctx, issueNumber)
+}
+
+// FindPR looks up the pull request for a branch in the wrapped GitHub
+func (g *RedactingGitHub) FindPR(ctx context.Context, branch string) (int, error) {
+	return g.GitHub.FindPR(ctx, branch)
+}
+
+// PRState looks up the pull request state for a branch in the wrapped GitHub
+func (g *RedactingGitHub) PRState(ctx context.Context, branch string) (string, error) {
+	return g.GitHub.PRState(ctx, branch)
+}
+
+// CurrentUser looks up the authenticated user in the wrapped GitHub
+func (g *RedactingGitHub) CurrentUser(ctx context.Context) (string, error) {
+	return g.GitHub.CurrentUser(ctx)
+}
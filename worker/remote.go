@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// repoNamePattern matches a valid GitHub owner or repository name
+var repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// DefaultMirrorDir returns the directory for repository mirrors, ~/.kratt/mirrors
+func DefaultMirrorDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".kratt", "mirrors"), nil
+}
+
+// SplitRepo splits "owner/repo" into its owner and repository name
+func SplitRepo(repo string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || !validRepoName(owner) || !validRepoName(name) {
+		return "", "", fmt.Errorf("invalid repository %q: expected owner/repo", repo)
+	}
+	return owner, name, nil
+}
+
+// validRepoName reports whether name is a GitHub owner or repository name
+// that is safe to use as a path component
+func validRepoName(name string) bool {
+	return repoNamePattern.MatchString(name) && name != "." && name != ".."
+}
+
+// ProcessRemotePR processes a pull request of a repository that does not
+// need to be checked out locally. The repository is kept as a bare mirror
+// below MirrorDir; the PR is processed in a worktree of the mirror that is
+// reset to the remote branch and removed afterwards, unless the run waits for
+// approval. GitHub must be configured for the same repository.
+func (w *Worker) ProcessRemotePR(ctx context.Context, repo string, prNumber int) error {
+	mirror, restore, err := w.enterMirror(ctx, repo)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	before, err := w.Git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The mirror's local branches are never updated, so always start from the remote branch
+	remote := *w
+	remote.ResetWorktrees = true
+	remote.repo = repo
+	err = remote.ProcessPR(ctx, prNumber)
+
+	if w.RequireApproval {
+		return err
+	}
+	cleanupCtx, cancel := cleanupContext(ctx)
+	defer cancel()
+	if cleanupErr := w.removeNewWorktrees(cleanupCtx, mirror, before); cleanupErr != nil && err == nil {
+		err = cleanupErr
+	}
+	return err
+}
+
+// enterMirror syncs the bare mirror of repo below MirrorDir and changes into
+// it; restore changes back to the original directory
+func (w *Worker) enterMirror(ctx context.Context, repo string) (mirror string, restore func(), err error) {
+	owner, name, err := SplitRepo(repo)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mirrorDir := w.MirrorDir
+	if mirrorDir == "" {
+		mirrorDir, err = DefaultMirrorDir()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	mirror = filepath.Join(mirrorDir, owner, name+".git")
+	url := fmt.Sprintf("https://github.com/%s/%s.git", owner, name)
+	if err := w.Git.SyncMirror(ctx, url, mirror); err != nil {
+		return "", nil, fmt.Errorf("failed to sync mirror of %s: %w", repo, err)
+	}
+
+	original, err := w.Git.CurrentDirectory(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	restore = func() {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		w.Git.ChangeDirectory(cleanupCtx, original)
+	}
+	if err := w.Git.ChangeDirectory(ctx, mirror); err != nil {
+		restore()
+		return "", nil, fmt.Errorf("failed to change directory: %w", err)
+	}
+	return mirror, restore, nil
+}
+
+// removeNewWorktrees removes the worktrees of the repository at dir that are
+// not in before
+func (w *Worker) removeNewWorktrees(ctx context.Context, dir string, before []Worktree) error {
+	if err := w.Git.ChangeDirectory(ctx, dir); err != nil {
+		return fmt.Errorf("failed to change directory: %w", err)
+	}
+	after, err := w.Git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for _, worktree := range before {
+		existing[worktree.Path] = true
+	}
+	for _, worktree := range after {
+		if worktree.Main || existing[worktree.Path] {
+			continue
+		}
+		if err := w.Git.RemoveWorktree(ctx, worktree.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWorkerProcessRemotePR(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGitHub := NewFakeGitHub()
+	worker := newTestWorker(fakeGit, fakeGitHub, 42, "feature-branch", func(w *Worker) { w.MirrorDir = "/cache/mirrors" })
+
+	if err := worker.ProcessRemotePR(context.Background(), "acme/widgets", 42); err != nil {
+		t.Fatalf("ProcessRemotePR failed: %v", err)
+	}
+
+	if url := fakeGit.GetMirrors()["/cache/mirrors/acme/widgets.git"]; url != "https://github.com/acme/widgets.git" {
+		t.Errorf("Expected mirror of acme/widgets, got %v", fakeGit.GetMirrors())
+	}
+	resets := fakeGit.GetResets()
+	if len(resets) != 1 || resets[0] != "origin/feature-branch" {
+		t.Errorf("Expected worktree reset to origin/feature-branch, got %v", resets)
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "feature-branch"); exists {
+		t.Error("Expected disposable worktree to be removed")
+	}
+	if dir := fakeGit.GetCurrentDir(); dir != "/fake/repo" {
+		t.Errorf("Expected to return to /fake/repo, got %s", dir)
+	}
+	if len(fakeGitHub.GetComments(42)) == 0 {
+		t.Error("Expected comment to be posted")
+	}
+	if worker.ResetWorktrees {
+		t.Error("Expected worker configuration to be left unchanged")
+	}
+	if runs, _ := worker.Runs.ListRuns(); len(runs) != 1 || runs[0].Repo != "acme/widgets" {
+		t.Errorf("Expected the run to record its repository, got %+v", runs)
+	}
+}
+
+func TestWorkerProcessRemotePRKeepsWorktreeAwaitingApproval(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	worker := newTestWorker(fakeGit, NewFakeGitHub(), 42, "feature-branch", func(w *Worker) { w.MirrorDir = "/cache/mirrors" })
+	worker.RequireApproval = true
+
+	if err := worker.ProcessRemotePR(context.Background(), "acme/widgets", 42); err != nil {
+		t.Fatalf("ProcessRemotePR failed: %v", err)
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "feature-branch"); !exists {
+		t.Error("Expected worktree of a run awaiting approval to be kept")
+	}
+}
+
+func TestSplitRepo(t *testing.T) {
+	owner, name, err := SplitRepo("acme/widgets")
+	if err != nil || owner != "acme" || name != "widgets" {
+		t.Errorf("SplitRepo(acme/widgets) = %q, %q, %v", owner, name, err)
+	}
+	for _, repo := range []string{"acme", "acme/", "/widgets", "acme/widgets/extra", "../widgets", "acme/.."} {
+		if _, _, err := SplitRepo(repo); err == nil {
+			t.Errorf("Expected SplitRepo(%q) to fail", repo)
+		}
+	}
+}
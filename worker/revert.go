@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RevertRun undoes the pushed changes of a run, BaseSHA..CommitSHA, on the
+// run's branch and notes on the PR who reverted them. By default a revert
+// commit is pushed. With reset, the branch is reset to BaseSHA and
+// force-pushed with lease instead; this is refused if anything was pushed
+// on top of the run.
+func (w *Worker) RevertRun(ctx context.Context, runID string, reset bool) error {
+	if w.Runs == nil {
+		return fmt.Errorf("reverting requires run history")
+	}
+	run, err := w.Runs.GetRun(runID)
+	if err != nil {
+		return err
+	}
+	if err := revertable(run); err != nil {
+		return err
+	}
+
+	user, err := w.GitHub.CurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	original, err := w.Git.CurrentDirectory(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		w.Git.ChangeDirectory(cleanupCtx, original)
+	}()
+
+	// The branch of a remote run is checked out from the mirror of its
+	// repository, in a worktree that is removed again afterwards
+	if run.Repo != "" {
+		mirror, restore, err := w.enterMirror(ctx, run.Repo)
+		if err != nil {
+			return err
+		}
+		defer restore()
+		before, err := w.Git.ListWorktrees(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			cleanupCtx, cancel := cleanupContext(ctx)
+			defer cancel()
+			w.removeNewWorktrees(cleanupCtx, mirror, before)
+		}()
+	}
+
+	// Enter the branch's worktree as a run would, so that it is up to date
+	// with origin and free of leftovers
+	if err := w.enterWorktree(ctx, &RunRecord{ID: run.ID, PRNumber: run.PRNumber, Branch: run.Branch}); err != nil {
+		return err
+	}
+	commits, err := w.Git.CommitsSince(ctx, run.BaseSHA)
+	if err != nil {
+		return err
+	}
+	later, found := commitsAfter(commits, run.CommitSHA)
+	if !found {
+		return fmt.Errorf("branch %s no longer contains commit %s of run %s", run.Branch, shortSHA(run.CommitSHA), run.ID)
+	}
+
+	err = w.runPhase(ctx, PhasePush, func(ctx context.Context) error {
+		if reset {
+			return w.resetRun(ctx, run, later)
+		}
+		return w.revertRun(ctx, run, user)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revert run %s: %w", run.ID, err)
+	}
+
+	run.Status = RunStatusReverted
+	run.RevertedBy = user
+	run.FinishedAt = time.Now()
+	if err := w.saveRun(run); err != nil {
+		return err
+	}
+	return w.GitHub.PostComment(ctx, run.PRNumber, formatRevertComment(run))
+}
+
+// revertable checks that a run pushed changes that can be reverted
+func revertable(run *RunRecord) error {
+	switch {
+	case run.Status == RunStatusReverted:
+		return fmt.Errorf("run %s was already reverted", run.ID)
+	case run.Status != RunStatusSucceeded:
+		return fmt.Errorf("run %s is %s; only succeeded runs can be reverted", run.ID, run.Status)
+	case len(run.Comparison) > 0:
+		return fmt.Errorf("run %s is a comparison; its side branches can be deleted instead", run.ID)
+	case run.BaseSHA == "" || run.CommitSHA == "" || run.Branch == "":
+		return fmt.Errorf("run %s has no recorded commits to revert", run.ID)
+	case run.BaseSHA == run.CommitSHA:
+		return fmt.Errorf("run %s did not change the branch", run.ID)
+	}
+	return nil
+}
+
+// commitsAfter returns the commits following sha and whether sha was found
+func commitsAfter(commits []CommitInfo, sha string) ([]CommitInfo, bool) {
+	for i, commit := range commits {
+		if commit.SHA == sha {
+			return commits[i+1:], true
+		}
+	}
+	return nil, false
+}
+
+// revertRun pushes a commit undoing the run's changes
+func (w *Worker) revertRun(ctx context.Context, run *RunRecord, user string) error {
+	if err := w.Git.Revert(ctx, run.BaseSHA, run.CommitSHA); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("revert: changes of kratt run %s\n\nThis reverts commits %s..%s, requested by @%s.\n\nKratt-Run: %s\nRefs: #%d\n",
+		run.ID, shortSHA(run.BaseSHA), shortSHA(run.CommitSHA), user, run.ID, run.PRNumber)
+	committed, err := w.Git.Commit(ctx, message)
+	if err != nil {
+		return err
+	}
+	if !committed {
+		return fmt.Errorf("the changes of run %s are already undone", run.ID)
+	}
+	if run.RevertSHA, err = w.Git.HeadSHA(ctx); err != nil {
+		return err
+	}
+	return w.Git.Push(ctx)
+}
+
+// resetRun resets the branch to the commit the run started from and
+// force-pushes it, provided origin still points at the run's last commit
+func (w *Worker) resetRun(ctx context.Context, run *RunRecord, later []CommitInfo) error {
+	if len(later) > 0 {
+		return fmt.Errorf("%d commit(s) were pushed after run %s and would be lost; revert instead of resetting", len(later), run.ID)
+	}
+	if err := w.Git.ResetHard(ctx, run.BaseSHA); err != nil {
+		return err
+	}
+	return w.Git.PushWithLease(ctx, run.CommitSHA)
+}
+
+// formatRevertComment notes on the PR that a run's changes were undone
+func formatRevertComment(run *RunRecord) string {
+	if run.RevertSHA == "" {
+		return fmt.Sprintf("↩️ **Reverted** by @%s: the branch was reset to `%s`, discarding the changes of run `%s`.", run.RevertedBy, shortSHA(run.BaseSHA), run.ID)
+	}
+	return fmt.Sprintf("↩️ **Reverted** by @%s: the changes of run `%s` (`%s..%s`) were undone by `%s`.", run.RevertedBy, run.ID, shortSHA(run.BaseSHA), shortSHA(run.CommitSHA), shortSHA(run.RevertSHA))
+}
@@ -0,0 +1,198 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	revertBaseSHA   = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	revertCommitSHA = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func TestRevertRun(t *testing.T) {
+	fakeGit, fakeGitHub := NewFakeLocalGit(), NewFakeGitHub()
+	fakeGitHub.SetCurrentUser("alice")
+	fakeGit.SetAgentCommits([]CommitInfo{{SHA: revertCommitSHA, Subject: "fix: parser"}})
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+	w.Runs.SaveRun(&RunRecord{ID: "r1", PRNumber: 7, Branch: "feature", Status: RunStatusSucceeded, BaseSHA: revertBaseSHA, CommitSHA: revertCommitSHA})
+
+	if err := w.RevertRun(context.Background(), "r1", false); err != nil {
+		t.Fatalf("RevertRun failed: %v", err)
+	}
+	if reverts := fakeGit.GetReverts(); len(reverts) != 1 || reverts[0] != revertBaseSHA+".."+revertCommitSHA {
+		t.Errorf("Expected the run's commits to be reverted, got %v", reverts)
+	}
+	if commits := fakeGit.GetCommits(); len(commits) != 1 || !strings.Contains(commits[0], "Kratt-Run: r1") || !strings.Contains(commits[0], "@alice") {
+		t.Errorf("Expected revert commit, got %v", commits)
+	}
+	if fakeGit.GetPushCount() != 1 {
+		t.Errorf("Expected revert to be pushed, got %d pushes", fakeGit.GetPushCount())
+	}
+	if dir := fakeGit.GetCurrentDir(); dir != "/fake/repo" {
+		t.Errorf("Expected to return to /fake/repo, got %s", dir)
+	}
+
+	run, _ := w.Runs.GetRun("r1")
+	if run.Status != RunStatusReverted || run.RevertedBy != "alice" || run.RevertSHA == "" {
+		t.Errorf("Expected revert to be recorded, got %+v", run)
+	}
+	if comments := fakeGitHub.GetComments(7); len(comments) != 1 || !strings.Contains(comments[0], "**Reverted** by @alice") {
+		t.Errorf("Expected revert note, got %v", comments)
+	}
+
+	if err := w.RevertRun(context.Background(), "r1", false); err == nil || !strings.Contains(err.Error(), "already reverted") {
+		t.Errorf("Expected second revert to be refused, got %v", err)
+	}
+}
+
+func TestRevertRunReset(t *testing.T) {
+	fakeGit, fakeGitHub := NewFakeLocalGit(), NewFakeGitHub()
+	w := newTestWorker(fakeGit, fakeGitHub, 7, "feature")
+	w.Runs.SaveRun(&RunRecord{ID: "r1", PRNumber: 7, Branch: "feature", Status: RunStatusSucceeded, BaseSHA: revertBaseSHA, CommitSHA: revertCommitSHA})
+
+	fakeGit.SetAgentCommits([]CommitInfo{{SHA: revertCommitSHA}, {SHA: "cccccccccccccccccccccccccccccccccccccccc"}})
+	if err := w.RevertRun(context.Background(), "r1", true); err == nil || !strings.Contains(err.Error(), "revert instead") {
+		t.Fatalf("Expected reset over later commits to be refused, got %v", err)
+	}
+	if len(fakeGit.GetLeases()) != 0 {
+		t.Error("Expected nothing to be force-pushed")
+	}
+
+	fakeGit.SetAgentCommits([]CommitInfo{{SHA: revertCommitSHA}})
+	if err := w.RevertRun(context.Background(), "r1", true); err != nil {
+		t.Fatalf("RevertRun failed: %v", err)
+	}
+	if resets := fakeGit.GetResets(); len(resets) != 1 || resets[0] != revertBaseSHA {
+		t.Errorf("Expected reset to the starting commit, got %v", resets)
+	}
+	if leases := fakeGit.GetLeases(); len(leases) != 1 || leases[0] != revertCommitSHA {
+		t.Errorf("Expected force-push with lease on the run's commit, got %v", leases)
+	}
+	if comments := fakeGitHub.GetComments(7); len(comments) != 1 || !strings.Contains(comments[0], "reset to `aaaaaaa`") {
+		t.Errorf("Expected reset note, got %v", comments)
+	}
+}
+
+func TestRevertRemoteRun(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetAgentCommits([]CommitInfo{{SHA: revertCommitSHA}})
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature", func(w *Worker) { w.MirrorDir = "/cache/mirrors" })
+	w.Runs.SaveRun(&RunRecord{ID: "r1", Repo: "acme/widgets", PRNumber: 7, Branch: "feature", Status: RunStatusSucceeded, BaseSHA: revertBaseSHA, CommitSHA: revertCommitSHA})
+
+	if err := w.RevertRun(context.Background(), "r1", false); err != nil {
+		t.Fatalf("RevertRun failed: %v", err)
+	}
+	if _, ok := fakeGit.GetMirrors()["/cache/mirrors/acme/widgets.git"]; !ok {
+		t.Errorf("Expected the run to be reverted in the mirror of acme/widgets, got %v", fakeGit.GetMirrors())
+	}
+	if fakeGit.GetPushCount() != 1 {
+		t.Errorf("Expected revert to be pushed, got %d pushes", fakeGit.GetPushCount())
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "feature"); exists {
+		t.Error("Expected the mirror's worktree to be removed")
+	}
+	if dir := fakeGit.GetCurrentDir(); dir != "/fake/repo" {
+		t.Errorf("Expected to return to /fake/repo, got %s", dir)
+	}
+}
+
+func TestRevertRunRefusesUnknownCommits(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	w := newTestWorker(fakeGit, NewFakeGitHub(), 7, "feature")
+	w.Runs.SaveRun(&RunRecord{ID: "r1", PRNumber: 7, Branch: "feature", Status: RunStatusSucceeded, BaseSHA: revertBaseSHA, CommitSHA: revertCommitSHA})
+
+	if err := w.RevertRun(context.Background(), "r1", false); err == nil || !strings.Contains(err.Error(), "no longer contains") {
+		t.Errorf("Expected rewritten branch to be refused, got %v", err)
+	}
+
+	w.Runs.SaveRun(&RunRecord{ID: "r2", PRNumber: 7, Branch: "feature", Status: RunStatusRejected, BaseSHA: revertBaseSHA, CommitSHA: revertCommitSHA})
+	if err := w.RevertRun(context.Background(), "r2", false); err == nil || !strings.Contains(err.Error(), "only succeeded runs") {
+		t.Errorf("Expected rejected run to be refused, got %v", err)
+	}
+}
+
+func TestGitRunnerRevert(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	repo := t.TempDir()
+	if err := initEvalRepo(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	original, _ := os.Getwd()
+	defer os.Chdir(original)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &GitRunner{Commits: CommitSettings{AuthorName: "test", AuthorEmail: "test@localhost"}}
+	base, _ := runner.HeadSHA(ctx)
+	for _, content := range []string{"one\n", "two\n"} {
+		os.WriteFile(filepath.Join(repo, "notes.txt"), []byte(content), 0644)
+		if _, err := runner.Commit(ctx, "docs: notes "+content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	head, _ := runner.HeadSHA(ctx)
+
+	if err := runner.Revert(ctx, base, head); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if committed, err := runner.Commit(ctx, "revert: notes"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected notes.txt to be removed by the revert, got %v", err)
+	}
+}
+
+func TestGitRunnerRevertMerge(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	repo := t.TempDir()
+	if err := initEvalRepo(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	original, _ := os.Getwd()
+	defer os.Chdir(original)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &GitRunner{Commits: CommitSettings{AuthorName: "test", AuthorEmail: "test@localhost"}}
+	base, _ := runner.HeadSHA(ctx)
+	// A side branch merged with a merge commit after a commit on the branch
+	if err := runGit(ctx, repo, "checkout", "-q", "-b", "side"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(repo, "side.txt"), []byte("side\n"), 0644)
+	if _, err := runner.Commit(ctx, "docs: side"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runGit(ctx, repo, "checkout", "-q", "-"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("notes\n"), 0644)
+	if _, err := runner.Commit(ctx, "docs: notes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runGit(ctx, repo, "-c", "user.name=test", "-c", "user.email=test@localhost", "merge", "-q", "--no-ff", "-m", "merge side", "side"); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := runner.HeadSHA(ctx)
+
+	if err := runner.Revert(ctx, base, head); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if committed, err := runner.Commit(ctx, "revert: merge"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+	for _, name := range []string{"side.txt", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(repo, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed by the revert, got %v", name, err)
+		}
+	}
+}
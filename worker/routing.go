@@ -0,0 +1,227 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Task types used for agent routing
+const (
+	TaskReview    = "review"
+	TaskImplement = "implement"
+	TaskFixTests  = "fix-tests"
+	TaskPlan      = "plan"
+)
+
+// AgentProfile is a named agent with its own timeout
+type AgentProfile struct {
+	Name    string
+	Agent   Agent
+	Timeout time.Duration // Zero means the worker deadline applies
+}
+
+// AgentRoute selects an agent chain for pull requests matching all of its
+// non-empty conditions
+type AgentRoute struct {
+	Label        string   // PR must carry this label
+	TaskType     string   // Worker task type must match
+	MinDiffLines int      // PR must change at least this many lines
+	MaxDiffLines int      // PR must change at most this many lines (zero: no limit)
+	Agents       []string // Names of the agent profiles to try, in order
+}
+
+// PRDetails holds the structured fields of the PR info used for routing
+type PRDetails struct {
+	Title       string `json:"title"`
+	HeadRefName string `json:"headRefName"`
+	Additions   int    `json:"additions"`
+	Deletions   int    `json:"deletions"`
+	Labels      []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Comments []PRComment `json:"comments"`
+}
+
+// PRComment is a comment on a pull request as returned by gh
+type PRComment struct {
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	AuthorAssociation string    `json:"authorAssociation"`
+	Body              string    `json:"body"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// parsePRDetails parses the PR info JSON; unparseable info yields empty details
+func parsePRDetails(prInfo string) PRDetails {
+	var details PRDetails
+	_ = json.Unmarshal([]byte(prInfo), &details)
+	return details
+}
+
+// DiffLines returns the total number of changed lines
+func (d PRDetails) DiffLines() int {
+	return d.Additions + d.Deletions
+}
+
+// HasLabel reports whether the PR carries the given label
+func (d PRDetails) HasLabel(label string) bool {
+	for _, l := range d.Labels {
+		if strings.EqualFold(l.Name, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the route applies to the PR and task type
+func (r AgentRoute) Matches(details PRDetails, taskType string) bool {
+	if r.Label != "" && !details.HasLabel(r.Label) {
+		return false
+	}
+	if r.TaskType != "" && r.TaskType != taskType {
+		return false
+	}
+	if r.MinDiffLines > 0 && details.DiffLines() < r.MinDiffLines {
+		return false
+	}
+	if r.MaxDiffLines > 0 && details.DiffLines() > r.MaxDiffLines {
+		return false
+	}
+	return true
+}
+
+// String describes the route's conditions
+func (r AgentRoute) String() string {
+	conditions := []string{}
+	if r.Label != "" {
+		conditions = append(conditions, "label="+r.Label)
+	}
+	if r.TaskType != "" {
+		conditions = append(conditions, "task="+r.TaskType)
+	}
+	if r.MinDiffLines > 0 {
+		conditions = append(conditions, fmt.Sprintf("diff>=%d", r.MinDiffLines))
+	}
+	if r.MaxDiffLines > 0 {
+		conditions = append(conditions, fmt.Sprintf("diff<=%d", r.MaxDiffLines))
+	}
+	if len(conditions) == 0 {
+		return "any"
+	}
+	return strings.Join(conditions, ",")
+}
+
+// selectAgents returns the agent chain for a PR and a description of the
+// route that selected it
+func (w *Worker) selectAgents(details PRDetails) ([]AgentProfile, string, error) {
+	if len(w.Agents) == 0 {
+		agent := w.agent()
+		return []AgentProfile{{Name: agent.Name(), Agent: agent}}, "default", nil
+	}
+
+	for _, route := range w.Routes {
+		if !route.Matches(details, w.TaskType) {
+			continue
+		}
+
+		chain := []AgentProfile{}
+		for _, name := range route.Agents {
+			profile, ok := w.agentProfile(name)
+			if !ok {
+				return nil, "", fmt.Errorf("route %s refers to unknown agent %q", route, name)
+			}
+			chain = append(chain, profile)
+		}
+		return chain, route.String(), nil
+	}
+
+	return w.Agents, "default", nil
+}
+
+// agentProfile looks up an agent profile by name
+func (w *Worker) agentProfile(name string) (AgentProfile, bool) {
+	for _, profile := range w.Agents {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return AgentProfile{}, false
+}
+
+// runAgentChain runs each agent in the chain until one succeeds, recording
+// every attempt in the run record
+func (w *Worker) runAgentChain(ctx context.Context, run *RunRecord, chain []AgentProfile, prompt string) (*AgentResult, error) {
+	var errs []error
+	for _, profile := range chain {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result, attempt, err := w.runAgentProfile(ctx, profile, prompt)
+		run.AgentAttempts = append(run.AgentAttempts, attempt)
+		if err == nil {
+			run.Agent = profile.Name
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", profile.Name, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no agent was run: %w", ctx.Err())
+	}
+	return nil, fmt.Errorf("all agents failed: %w", errors.Join(errs...))
+}
+
+// runAgentProfile runs a single agent profile with its own timeout
+func (w *Worker) runAgentProfile(ctx context.Context, profile AgentProfile, prompt string) (*AgentResult, AgentAttempt, error) {
+	if profile.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, profile.Timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	result, err := w.runAgent(ctx, profile.Agent, prompt)
+	attempt := AgentAttempt{
+		Agent:    profile.Name,
+		Duration: time.Since(started).Round(time.Millisecond),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+	}
+	return result, attempt, err
+}
+
+// formatAgentAttempts formats the agent selection and attempts as a comment section
+func formatAgentAttempts(run *RunRecord) string {
+	var section strings.Builder
+
+	section.WriteString("### Agent\n")
+	section.WriteString(fmt.Sprintf("**Agent:** %s (route: %s", run.Agent, run.Route))
+	if run.TaskType != "" {
+		section.WriteString(", task: " + run.TaskType)
+	}
+	section.WriteString(")\n")
+
+	if len(run.AgentAttempts) > 1 {
+		section.WriteString("\n**Attempts:**\n")
+		for _, attempt := range run.AgentAttempts {
+			switch {
+			case attempt.TimedOut:
+				section.WriteString(fmt.Sprintf("- %s: ⏱️ timed out after %s\n", attempt.Agent, attempt.Duration))
+			case attempt.Error != "":
+				section.WriteString(fmt.Sprintf("- %s: ❌ failed after %s\n", attempt.Agent, attempt.Duration))
+			default:
+				section.WriteString(fmt.Sprintf("- %s: ✅ succeeded in %s\n", attempt.Agent, attempt.Duration))
+			}
+		}
+	}
+
+	return section.String()
+}
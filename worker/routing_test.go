@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgentRouteMatches(t *testing.T) {
+	details := parsePRDetails(`{
+		"headRefName": "feature",
+		"additions": 30,
+		"deletions": 10,
+		"labels": [{"name": "Docs"}]
+	}`)
+
+	tests := []struct {
+		name     string
+		route    AgentRoute
+		taskType string
+		expected bool
+	}{
+		{"no conditions", AgentRoute{}, TaskReview, true},
+		{"label matches case-insensitively", AgentRoute{Label: "docs"}, TaskReview, true},
+		{"label missing", AgentRoute{Label: "security"}, TaskReview, false},
+		{"task matches", AgentRoute{TaskType: TaskFixTests}, TaskFixTests, true},
+		{"task differs", AgentRoute{TaskType: TaskFixTests}, TaskReview, false},
+		{"diff within max", AgentRoute{MaxDiffLines: 40}, TaskReview, true},
+		{"diff above max", AgentRoute{MaxDiffLines: 39}, TaskReview, false},
+		{"diff below min", AgentRoute{MinDiffLines: 100}, TaskReview, false},
+		{"all conditions", AgentRoute{Label: "docs", TaskType: TaskReview, MaxDiffLines: 50}, TaskReview, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.Matches(details, tt.taskType); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWorkerProcessPRAgentFallback(t *testing.T) {
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(5, `{"headRefName": "feature", "labels": [{"name": "tests"}]}`)
+
+	broken := NewFakeAgent("broken")
+	broken.Err = fmt.Errorf("service unavailable")
+	slow := NewFakeAgent("slow")
+	slow.Block = true
+	working := NewFakeAgent("working")
+	unused := NewFakeAgent("unused")
+
+	runs := NewFakeRunStore()
+	w := &Worker{
+		Instructions: "You are a helpful AI assistant.",
+		LintCommand:  []string{"go", "vet", "./..."},
+		TestCommand:  []string{"go", "test", "./..."},
+		Deadline:     5 * time.Second,
+		Agents: []AgentProfile{
+			{Name: "unused", Agent: unused},
+			{Name: "broken", Agent: broken},
+			{Name: "slow", Agent: slow, Timeout: 10 * time.Millisecond},
+			{Name: "working", Agent: working},
+		},
+		Routes: []AgentRoute{
+			{Label: "docs", Agents: []string{"unused"}},
+			{Label: "tests", TaskType: TaskFixTests, Agents: []string{"broken", "slow", "working"}},
+		},
+		TaskType: TaskFixTests,
+		Runs:     runs,
+		Git:      NewFakeLocalGit(),
+		GitHub:   fakeGitHub,
+		Runner:   NewFakeCommandRunner(),
+	}
+
+	if err := w.ProcessPR(context.Background(), 5); err != nil {
+		t.Fatalf("ProcessPR failed: %v", err)
+	}
+
+	if len(unused.GetPrompts()) != 0 {
+		t.Error("Expected agent outside the matching route not to run")
+	}
+
+	history, _ := runs.ListRuns()
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 run to be recorded, got %d", len(history))
+	}
+	run := history[0]
+	if run.Status != RunStatusSucceeded || run.Agent != "working" || run.Route != "label=tests,task=fix-tests" {
+		t.Errorf("Unexpected run record: %+v", run)
+	}
+	if len(run.AgentAttempts) != 3 || !run.AgentAttempts[1].TimedOut {
+		t.Errorf("Expected 3 attempts with the second timing out, got %+v", run.AgentAttempts)
+	}
+
+	comment := fakeGitHub.GetComments(5)[0]
+	for _, want := range []string{"**Agent:** working (route: label=tests,task=fix-tests", "- broken: ❌ failed", "- slow: ⏱️ timed out"} {
+		if !strings.Contains(comment, want) {
+			t.Errorf("Expected comment to contain %q, got:\n%s", want, comment)
+		}
+	}
+}
+
+func TestWorkerProcessPRAllAgentsFail(t *testing.T) {
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRInfo(5, `{"headRefName": "feature"}`)
+
+	broken := NewFakeAgent("broken")
+	broken.Err = fmt.Errorf("service unavailable")
+
+	runs := NewFakeRunStore()
+	w := &Worker{
+		Deadline: 5 * time.Second,
+		Agents:   []AgentProfile{{Name: "broken", Agent: broken}},
+		Runs:     runs,
+		Git:      NewFakeLocalGit(),
+		GitHub:   fakeGitHub,
+		Runner:   NewFakeCommandRunner(),
+	}
+
+	err := w.ProcessPR(context.Background(), 5)
+	if err == nil || !strings.Contains(err.Error(), "all agents failed") {
+		t.Fatalf("Expected all agents to fail, got %v", err)
+	}
+
+	history, _ := runs.ListRuns()
+	if len(history) != 1 || history[0].Status != RunStatusFailed {
+		t.Errorf("Expected failed run to be recorded, got %+v", history)
+	}
+}
@@ -0,0 +1,215 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Run statuses recorded in run history
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+	RunStatusCancelled = "cancelled"
+
+	RunStatusAwaitingApproval = "awaiting-approval"
+	RunStatusRejected         = "rejected"
+	RunStatusReverted         = "reverted"
+)
+
+// RunRecord is the history entry for a single worker run
+type RunRecord struct {
+	ID         string    `json:"id"`
+	Repo       string    `json:"repo,omitempty"` // owner/repo of a remote run; empty for the current directory's repository
+	PRNumber   int       `json:"pr_number"`
+	Branch     string    `json:"branch,omitempty"`
+	TaskType   string    `json:"task_type,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	// Route describes the routing rule that selected the agent chain
+	Route string `json:"route,omitempty"`
+	// Agent is the name of the agent whose attempt succeeded
+	Agent         string         `json:"agent,omitempty"`
+	AgentAttempts []AgentAttempt `json:"agent_attempts,omitempty"`
+
+	// Comparison holds the per-agent outcomes of a compare mode run
+	Comparison []ComparisonEntry `json:"comparison,omitempty"`
+
+	// Worktree is the directory the run worked in
+	Worktree string `json:"worktree,omitempty"`
+	// WorktreeCreated is set when this run created the worktree
+	WorktreeCreated bool `json:"worktree_created,omitempty"`
+	// Recovered lists the leftovers of earlier runs that were cleaned up
+	// according to the dirty worktree policy
+	Recovered []string `json:"recovered,omitempty"`
+
+	// BaseSHA is the commit the agent started from; CommitSHA is the last
+	// commit the run pushed, or its local commit while it awaits approval
+	BaseSHA             string    `json:"base_sha,omitempty"`
+	CommitSHA           string    `json:"commit_sha,omitempty"`
+	ApprovalRequestedAt time.Time `json:"approval_requested_at,omitempty"`
+	// PlanFile is the implementation status file of a plan mode run
+	PlanFile string `json:"plan_file,omitempty"`
+	// StepsCompleted counts the plan steps completed by this run
+	StepsCompleted int `json:"steps_completed,omitempty"`
+	// ReviewedBy is who approved or rejected the run
+	ReviewedBy string `json:"reviewed_by,omitempty"`
+
+	// RevertedBy is who reverted the run's changes; RevertSHA is the revert
+	// commit, empty if the branch was reset instead
+	RevertedBy string `json:"reverted_by,omitempty"`
+	RevertSHA  string `json:"revert_sha,omitempty"`
+
+	// AgentCommits are the commits the agent created itself
+	AgentCommits []CommitInfo `json:"agent_commits,omitempty"`
+
+	// SecretFindings locates possible secrets that blocked the push; values are masked
+	SecretFindings []SecretFinding `json:"secret_findings,omitempty"`
+}
+
+// AgentAttempt records one agent invocation in a fallback chain
+type AgentAttempt struct {
+	Agent    string        `json:"agent"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+}
+
+// RunStore interface encapsulates persistence of run history
+type RunStore interface {
+	// SaveRun creates or updates a run record
+	SaveRun(run *RunRecord) error
+
+	// GetRun retrieves a run record by ID
+	GetRun(id string) (*RunRecord, error)
+
+	// ListRuns returns all run records, oldest first
+	ListRuns() ([]*RunRecord, error)
+}
+
+// newRunID creates a unique, sortable run ID for a pull request; the
+// nanoseconds keep runs started within the same second apart
+func newRunID(prNumber int, now time.Time) string {
+	now = now.UTC()
+	return fmt.Sprintf("%s-%09d-pr%d", now.Format("20060102-150405"), now.Nanosecond(), prNumber)
+}
+
+// DefaultRunDir returns the default directory for run history (~/.kratt/runs)
+func DefaultRunDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".kratt", "runs"), nil
+}
+
+// FileRunStore implements RunStore interface with one JSON file per run
+type FileRunStore struct {
+	Dir string
+}
+
+// SaveRun writes the run record to <Dir>/<id>.json
+func (s *FileRunStore) SaveRun(run *RunRecord) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create run directory %s: %w", s.Dir, err)
+	}
+
+	content, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run %s: %w", run.ID, err)
+	}
+
+	path := filepath.Join(s.Dir, run.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write run %s: %w", run.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// GetRun reads the run record with the given ID
+func (s *FileRunStore) GetRun(id string) (*RunRecord, error) {
+	content, err := os.ReadFile(filepath.Join(s.Dir, id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run %s: %w", id, err)
+	}
+
+	var run RunRecord
+	if err := json.Unmarshal(content, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse run %s: %w", id, err)
+	}
+	return &run, nil
+}
+
+// ListRuns reads all run records in the directory, oldest first
+func (s *FileRunStore) ListRuns() ([]*RunRecord, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return []*RunRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs in %s: %w", s.Dir, err)
+	}
+
+	runs := []*RunRecord{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		run, err := s.GetRun(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID < runs[j].ID })
+	return runs, nil
+}
+
+// FakeRunStore implements RunStore interface for testing
+type FakeRunStore struct {
+	runs map[string]RunRecord
+}
+
+// NewFakeRunStore creates a new FakeRunStore instance
+func NewFakeRunStore() *FakeRunStore {
+	return &FakeRunStore{runs: make(map[string]RunRecord)}
+}
+
+// SaveRun stores a copy of the run record in memory
+func (f *FakeRunStore) SaveRun(run *RunRecord) error {
+	f.runs[run.ID] = *run
+	return nil
+}
+
+// GetRun returns a copy of the stored run record
+func (f *FakeRunStore) GetRun(id string) (*RunRecord, error) {
+	run, exists := f.runs[id]
+	if !exists {
+		return nil, fmt.Errorf("run %s not found", id)
+	}
+	return &run, nil
+}
+
+// ListRuns returns copies of all stored run records, oldest first
+func (f *FakeRunStore) ListRuns() ([]*RunRecord, error) {
+	runs := []*RunRecord{}
+	for _, run := range f.runs {
+		runs = append(runs, &run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID < runs[j].ID })
+	return runs, nil
+}
@@ -0,0 +1,279 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Sandbox backends accepted by SandboxConfig
+const (
+	SandboxBubblewrap = "bwrap"
+	SandboxDocker     = "docker"
+	SandboxPodman     = "podman"
+)
+
+// DefaultSandboxEnv lists the environment variables passed into the sandbox
+// when SandboxConfig.Env is empty
+var DefaultSandboxEnv = []string{"PATH", "HOME", "USER", "LANG", "TERM"}
+
+// SandboxConfig describes the isolated environment commands run in
+type SandboxConfig struct {
+	Backend   string   // bwrap, docker or podman
+	Image     string   // Container image (docker and podman only)
+	Env       []string // Names of environment variables passed through; DefaultSandboxEnv when empty
+	ReadOnly  []string // Additional host paths mounted read-only, e.g. agent credentials
+	Writable  []string // Additional host paths mounted writable
+	NoNetwork bool     // Run without network access
+	Memory    string   // Memory limit, e.g. "4g"; bwrap needs systemd-run for it
+	CPUs      string   // CPU limit, e.g. "2" (docker and podman only)
+	Pids      int      // Maximum number of processes
+}
+
+// Validate checks that the configuration is complete for its backend
+func (c SandboxConfig) Validate() error {
+	switch c.Backend {
+	case SandboxBubblewrap:
+		if c.CPUs != "" {
+			return fmt.Errorf("sandbox backend %s does not support CPU limits", c.Backend)
+		}
+	case SandboxDocker, SandboxPodman:
+		if c.Image == "" {
+			return fmt.Errorf("sandbox backend %s requires an image", c.Backend)
+		}
+	default:
+		return fmt.Errorf("unknown sandbox backend %q: must be one of %s, %s, %s", c.Backend, SandboxBubblewrap, SandboxDocker, SandboxPodman)
+	}
+
+	if c.Memory != "" {
+		if _, err := parseMemory(c.Memory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SandboxRunner implements CommandRunner interface by running every command
+// inside an isolated environment. Only the current directory (the worktree),
+// its git common directory and kratt's scratch directory are writable.
+type SandboxRunner struct {
+	Config SandboxConfig
+	Runner CommandRunner // Runs the wrapped command, usually an ExecRunner
+}
+
+// RunWithStdin executes the command in the sandbox with the given stdin input
+func (s *SandboxRunner) RunWithStdin(ctx context.Context, stdin string, command string, args ...string) error {
+	command, args, err := s.wrap(command, args)
+	if err != nil {
+		return err
+	}
+	return s.Runner.RunWithStdin(ctx, stdin, command, args...)
+}
+
+// RunWithOutput executes the command in the sandbox and returns interleaved stdout/stderr output
+func (s *SandboxRunner) RunWithOutput(ctx context.Context, command string, args ...string) (output []byte, err error) {
+	command, args, err = s.wrap(command, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Runner.RunWithOutput(ctx, command, args...)
+}
+
+// RunWithStdinOutput executes the command in the sandbox with the given stdin input and returns its stdout
+func (s *SandboxRunner) RunWithStdinOutput(ctx context.Context, stdin string, command string, args ...string) (output []byte, err error) {
+	command, args, err = s.wrap(command, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Runner.RunWithStdinOutput(ctx, stdin, command, args...)
+}
+
+// wrap rewrites a command so that it runs inside the sandbox
+func (s *SandboxRunner) wrap(command string, args []string) (string, []string, error) {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to determine sandbox working directory: %w", err)
+	}
+	scratch, err := scratchDir()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// A linked worktree keeps its index, refs and objects in the common
+	// directory of the repository it was added to, so git inside the
+	// sandbox can only commit when that directory is writable too
+	config := s.Config
+	if common := gitCommonDir(workdir); common != "" && !withinDir(workdir, common) {
+		config.Writable = append(append([]string{}, config.Writable...), common)
+	}
+
+	switch config.Backend {
+	case SandboxBubblewrap:
+		return bubblewrapCommand(config, workdir, scratch, command, args)
+	case SandboxDocker, SandboxPodman:
+		return containerCommand(config, workdir, scratch, command, args)
+	default:
+		return "", nil, config.Validate()
+	}
+}
+
+// gitCommonDir returns the absolute git common directory of the repository
+// containing dir, or "" when dir is not inside a repository
+func gitCommonDir(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--path-format=absolute", "--git-common-dir")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// withinDir reports whether path is dir or lies below it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// sandboxEnv returns the names of the environment variables passed into the sandbox
+func sandboxEnv(config SandboxConfig) []string {
+	if len(config.Env) == 0 {
+		return DefaultSandboxEnv
+	}
+	return config.Env
+}
+
+// bubblewrapCommand builds a bwrap invocation with a read-only root, private
+// /tmp and home, and writable binds for the worktree and scratch directory.
+// A memory limit runs it in a systemd-run scope.
+func bubblewrapCommand(config SandboxConfig, workdir, scratch, command string, args []string) (string, []string, error) {
+	wrapped := []string{
+		"--die-with-parent",
+		"--unshare-all",
+	}
+	if !config.NoNetwork {
+		wrapped = append(wrapped, "--share-net")
+	}
+
+	wrapped = append(wrapped,
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	)
+	if home, err := os.UserHomeDir(); err == nil {
+		wrapped = append(wrapped, "--tmpfs", home)
+	}
+	for _, path := range config.ReadOnly {
+		wrapped = append(wrapped, "--ro-bind", path, path)
+	}
+	for _, path := range append(append([]string{}, config.Writable...), scratch, workdir) {
+		wrapped = append(wrapped, "--bind", path, path)
+	}
+	wrapped = append(wrapped, "--chdir", workdir, "--clearenv")
+	for _, name := range sandboxEnv(config) {
+		if value, ok := os.LookupEnv(name); ok {
+			wrapped = append(wrapped, "--setenv", name, value)
+		}
+	}
+	wrapped = append(wrapped, "--")
+
+	if config.Pids > 0 {
+		wrapped = append(wrapped, "prlimit", fmt.Sprintf("--nproc=%d", config.Pids), "--")
+	}
+
+	wrapped = append(wrapped, command)
+	wrapped = append(wrapped, args...)
+	if config.Memory == "" {
+		return "bwrap", wrapped, nil
+	}
+
+	// Limiting the address space with prlimit would break Go test binaries
+	// and JVMs, which reserve far more than they use; a transient cgroup
+	// scope limits the memory actually used instead
+	bytes, err := parseMemory(config.Memory)
+	if err != nil {
+		return "", nil, err
+	}
+	scope := []string{"--user", "--scope", "--quiet", "-p", fmt.Sprintf("MemoryMax=%d", bytes), "--", "bwrap"}
+	return "systemd-run", append(scope, wrapped...), nil
+}
+
+// containerCommand builds a docker or podman run invocation that mounts the
+// worktree and scratch directory at their host paths
+func containerCommand(config SandboxConfig, workdir, scratch, command string, args []string) (string, []string, error) {
+	wrapped := []string{"run", "--rm", "-i", "--init"}
+	if config.NoNetwork {
+		wrapped = append(wrapped, "--network", "none")
+	}
+	if config.Memory != "" {
+		wrapped = append(wrapped, "--memory", config.Memory)
+	}
+	if config.CPUs != "" {
+		wrapped = append(wrapped, "--cpus", config.CPUs)
+	}
+	if config.Pids > 0 {
+		wrapped = append(wrapped, "--pids-limit", strconv.Itoa(config.Pids))
+	}
+	wrapped = append(wrapped, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+
+	for _, path := range config.ReadOnly {
+		wrapped = append(wrapped, "-v", path+":"+path+":ro")
+	}
+	for _, path := range append(append([]string{}, config.Writable...), scratch, workdir) {
+		wrapped = append(wrapped, "-v", path+":"+path)
+	}
+	wrapped = append(wrapped, "-w", workdir)
+	for _, name := range sandboxEnv(config) {
+		if _, ok := os.LookupEnv(name); ok {
+			wrapped = append(wrapped, "-e", name)
+		}
+	}
+
+	wrapped = append(wrapped, config.Image, command)
+	wrapped = append(wrapped, args...)
+	return config.Backend, wrapped, nil
+}
+
+// parseMemory parses a memory size such as "512m" or "4g" into bytes
+func parseMemory(size string) (int64, error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	i := strings.IndexFunc(size, func(r rune) bool { return !unicode.IsDigit(r) })
+	number, unit := size, ""
+	if i >= 0 {
+		number, unit = size[:i], strings.TrimSuffix(size[i:], "b")
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q", size)
+	}
+
+	switch unit {
+	case "":
+	case "k":
+		value <<= 10
+	case "m":
+		value <<= 20
+	case "g":
+		value <<= 30
+	default:
+		return 0, fmt.Errorf("invalid memory size %q", size)
+	}
+	return value, nil
+}
+
+// scratchDir returns kratt's directory for temporary files shared with the
+// agent, such as prompt and result files. Sandboxes mount it writable.
+func scratchDir() (string, error) {
+	dir := filepath.Join(os.TempDir(), "kratt")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create scratch directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSandboxRunnerBubblewrap(t *testing.T) {
+	t.Setenv("SECRET_TOKEN", "hunter2")
+	t.Setenv("LANG", "C.UTF-8")
+
+	fakeRunner := NewFakeCommandRunner()
+	runner := &SandboxRunner{
+		Config: SandboxConfig{
+			Backend:   SandboxBubblewrap,
+			ReadOnly:  []string{"/etc/agent"},
+			NoNetwork: true,
+			Memory:    "1g",
+			Pids:      256,
+		},
+		Runner: fakeRunner,
+	}
+
+	if err := runner.RunWithStdin(context.Background(), "prompt", "claude", "-p"); err != nil {
+		t.Fatalf("RunWithStdin failed: %v", err)
+	}
+
+	workdir, _ := os.Getwd()
+	scratch, _ := scratchDir()
+	command := fakeRunner.GetCommands()[0]
+	for _, want := range []string{
+		"systemd-run --user --scope --quiet -p MemoryMax=1073741824 -- bwrap --die-with-parent --unshare-all --ro-bind / /",
+		"--ro-bind /etc/agent /etc/agent",
+		"--bind " + scratch + " " + scratch,
+		"--bind " + workdir + " " + workdir,
+		"--chdir " + workdir + " --clearenv",
+		"--setenv LANG C.UTF-8",
+		"-- prlimit --nproc=256 -- claude -p",
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("Expected command to contain %q, got %s", want, command)
+		}
+	}
+	if strings.Contains(command, "--share-net") || strings.Contains(command, "hunter2") {
+		t.Errorf("Expected no network and a scrubbed environment, got %s", command)
+	}
+}
+
+func TestSandboxRunnerContainer(t *testing.T) {
+	t.Setenv("SECRET_TOKEN", "hunter2")
+	t.Setenv("ANTHROPIC_API_KEY", "key")
+
+	fakeRunner := NewFakeCommandRunner()
+	runner := &SandboxRunner{
+		Config: SandboxConfig{
+			Backend: SandboxPodman,
+			Image:   "kratt-agent:latest",
+			Env:     []string{"ANTHROPIC_API_KEY"},
+			CPUs:    "2",
+		},
+		Runner: fakeRunner,
+	}
+
+	if _, err := runner.RunWithOutput(context.Background(), "go", "test", "./..."); err != nil {
+		t.Fatalf("RunWithOutput failed: %v", err)
+	}
+
+	workdir, _ := os.Getwd()
+	command := fakeRunner.GetCommands()[0]
+	for _, want := range []string{
+		"podman run --rm -i --init --cpus 2",
+		"-v " + workdir + ":" + workdir + " -w " + workdir,
+		"-e ANTHROPIC_API_KEY kratt-agent:latest go test ./...",
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("Expected command to contain %q, got %s", want, command)
+		}
+	}
+	if strings.Contains(command, "SECRET_TOKEN") || strings.Contains(command, "--network") {
+		t.Errorf("Unexpected environment or network flags: %s", command)
+	}
+}
+
+func TestSandboxRunnerMountsGitCommonDir(t *testing.T) {
+	requireGit(t)
+	ctx := context.Background()
+	tmp, _ := filepath.EvalSymlinks(t.TempDir())
+	repo := filepath.Join(tmp, "repo")
+	if err := os.Mkdir(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initEvalRepo(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(tmp, "repo-feature")
+	if err := runGit(ctx, repo, "worktree", "add", "--quiet", "-b", "feature", worktree); err != nil {
+		t.Fatal(err)
+	}
+
+	original, _ := os.Getwd()
+	defer os.Chdir(original)
+	if err := os.Chdir(worktree); err != nil {
+		t.Fatal(err)
+	}
+
+	common := filepath.Join(repo, ".git")
+	for backend, want := range map[string]string{
+		SandboxBubblewrap: "--bind " + common + " " + common,
+		SandboxDocker:     "-v " + common + ":" + common,
+	} {
+		fakeRunner := NewFakeCommandRunner()
+		runner := &SandboxRunner{Config: SandboxConfig{Backend: backend, Image: "kratt-agent:latest"}, Runner: fakeRunner}
+		if _, err := runner.RunWithOutput(ctx, "git", "commit"); err != nil {
+			t.Fatalf("RunWithOutput failed: %v", err)
+		}
+		if command := fakeRunner.GetCommands()[0]; !strings.Contains(command, want) {
+			t.Errorf("Expected %s to mount the git common dir writable with %q, got %s", backend, want, command)
+		}
+	}
+}
+
+func TestSandboxConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SandboxConfig
+		valid  bool
+	}{
+		{"bwrap", SandboxConfig{Backend: SandboxBubblewrap, Memory: "512m"}, true},
+		{"docker with image", SandboxConfig{Backend: SandboxDocker, Image: "golang"}, true},
+		{"docker without image", SandboxConfig{Backend: SandboxDocker}, false},
+		{"bwrap with cpus", SandboxConfig{Backend: SandboxBubblewrap, CPUs: "2"}, false},
+		{"invalid memory", SandboxConfig{Backend: SandboxBubblewrap, Memory: "lots"}, false},
+		{"unknown backend", SandboxConfig{Backend: "chroot"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
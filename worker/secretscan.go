@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// DefaultEntropyThreshold is the Shannon entropy in bits per character above
+// which a long token is reported as a possible secret
+const DefaultEntropyThreshold = 4.5
+
+// minEntropyTokenLength is the shortest token checked for high entropy
+const minEntropyTokenLength = 32
+
+// DefaultSecretScanIgnore lists files whose content is expected to be high-entropy
+var DefaultSecretScanIgnore = []string{"go.sum", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock"}
+
+// entropyTokenPattern finds base64 and URL-safe token candidates
+var entropyTokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+
+// SecretScanner finds credentials in the lines added by a diff
+type SecretScanner struct {
+	Known            []string // Secret values that must never be committed, e.g. SecretEnvValues()
+	EntropyThreshold float64  // Zero means DefaultEntropyThreshold
+	Ignore           []string // Globs of paths that are not scanned; nil means DefaultSecretScanIgnore
+}
+
+// SecretFinding is a possible secret in a diff. Value is always masked.
+type SecretFinding struct {
+	Path  string `json:"path"`
+	Line  int    `json:"line"`
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// SecretsFoundError is returned when the staged changes contain possible secrets
+type SecretsFoundError struct {
+	Findings []SecretFinding
+}
+
+func (e *SecretsFoundError) Error() string {
+	locations := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		locations[i] = fmt.Sprintf("%s:%d (%s)", f.Path, f.Line, f.Kind)
+	}
+	return "possible secrets found: " + strings.Join(locations, ", ")
+}
+
+// Scan checks the lines added by a unified diff for known secret values,
+// credential formats and high-entropy strings
+func (s *SecretScanner) Scan(diff string) []SecretFinding {
+	threshold := s.EntropyThreshold
+	if threshold == 0 {
+		threshold = DefaultEntropyThreshold
+	}
+	ignore := s.Ignore
+	if ignore == nil {
+		ignore = DefaultSecretScanIgnore
+	}
+
+	var findings []SecretFinding
+	for _, change := range parseUnifiedDiff(diff) {
+		if matchesAnyGlob(ignore, change.Path) {
+			continue
+		}
+		for _, line := range change.AddedLines {
+			if finding, ok := s.scanLine(line.Text, threshold); ok {
+				finding.Path = change.Path
+				finding.Line = line.Number
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// scanLine returns the first possible secret on a line
+func (s *SecretScanner) scanLine(line string, threshold float64) (SecretFinding, bool) {
+	for _, known := range s.Known {
+		if len(known) >= minSecretLength && strings.Contains(line, known) {
+			return SecretFinding{Kind: "known secret", Value: "****"}, true
+		}
+	}
+	for _, pattern := range secretPatterns {
+		if match := pattern.re.FindString(line); match != "" {
+			return SecretFinding{Kind: pattern.kind, Value: maskSecret(match)}, true
+		}
+	}
+	for _, token := range entropyTokenPattern.FindAllString(line, -1) {
+		if len(token) >= minEntropyTokenLength && shannonEntropy(token) >= threshold {
+			return SecretFinding{Kind: "high-entropy string", Value: maskSecret(token)}, true
+		}
+	}
+	return SecretFinding{}, false
+}
+
+// matchesAnyGlob reports whether path matches one of the globs
+func matchesAnyGlob(globs []string, path string) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var entropy float64
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// maskSecret keeps only a short prefix of a secret so that findings can be located
+func maskSecret(secret string) string {
+	if len(secret) < 12 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// formatSecretFindings renders secret scan findings for the PR comment
+func formatSecretFindings(findings []SecretFinding) string {
+	var b strings.Builder
+	b.WriteString("### Secret Scan\n\n")
+	b.WriteString("🔐 **Push blocked:** the changes contain possible secrets. Remove them and rotate any real credentials.\n\n")
+	for _, f := range findings {
+		fmt.Fprintf(&b, "- `%s:%d`: %s `%s`\n", f.Path, f.Line, f.Kind, f.Value)
+	}
+	return b.String()
+}
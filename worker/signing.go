@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Commit signing formats
+const (
+	SigningSSH = "ssh"
+	SigningGPG = "gpg"
+)
+
+// CommitSettings configures the identity, signature and sign-off of the
+// commits the worker creates. The zero value commits as configured in git.
+type CommitSettings struct {
+	AuthorName     string // Bot author; empty uses the git configuration
+	AuthorEmail    string
+	CommitterName  string // Defaults to the author
+	CommitterEmail string
+
+	SigningFormat string // SigningSSH or SigningGPG; empty leaves signing to the git configuration
+	SigningKey    string // Path to the SSH key, or the GPG key ID
+
+	SignOff bool // Adds a Signed-off-by trailer for the committer
+}
+
+// Validate checks that identities are complete and signing has a key
+func (s CommitSettings) Validate() error {
+	if (s.AuthorName == "") != (s.AuthorEmail == "") {
+		return fmt.Errorf("author needs both name and email")
+	}
+	if (s.CommitterName == "") != (s.CommitterEmail == "") {
+		return fmt.Errorf("committer needs both name and email")
+	}
+	switch s.SigningFormat {
+	case "":
+		if s.SigningKey != "" {
+			return fmt.Errorf("signing key without signing format")
+		}
+	case SigningSSH, SigningGPG:
+		if s.SigningKey == "" {
+			return fmt.Errorf("%s signing requires a key", s.SigningFormat)
+		}
+	default:
+		return fmt.Errorf("invalid signing format %q: must be %s or %s", s.SigningFormat, SigningSSH, SigningGPG)
+	}
+	return nil
+}
+
+// committer returns the committer identity, falling back to the author
+func (s CommitSettings) committer() (string, string) {
+	if s.CommitterName != "" {
+		return s.CommitterName, s.CommitterEmail
+	}
+	return s.AuthorName, s.AuthorEmail
+}
+
+// commitArgs returns the git arguments for committing with the message
+func (s CommitSettings) commitArgs(message string) []string {
+	args := []string{}
+	switch s.SigningFormat {
+	case SigningSSH:
+		args = append(args, "-c", "gpg.format=ssh")
+	case SigningGPG:
+		args = append(args, "-c", "gpg.format=openpgp")
+	}
+	args = append(args, "commit", "-m", message)
+	if s.SigningFormat != "" {
+		args = append(args, "--gpg-sign="+s.SigningKey)
+	}
+	if s.SignOff {
+		args = append(args, "--signoff")
+	}
+	return args
+}
+
+// commitEnv returns the environment variables overriding author and committer
+func (s CommitSettings) commitEnv() []string {
+	env := []string{}
+	if s.AuthorName != "" {
+		env = append(env, "GIT_AUTHOR_NAME="+s.AuthorName, "GIT_AUTHOR_EMAIL="+s.AuthorEmail)
+	}
+	if name, email := s.committer(); name != "" {
+		env = append(env, "GIT_COMMITTER_NAME="+name, "GIT_COMMITTER_EMAIL="+email)
+	}
+	return env
+}
+
+// appendTrailer adds a trailer to a commit message, starting a trailer
+// paragraph unless the message already ends with one
+func appendTrailer(message, key, value string) string {
+	message = strings.TrimRight(message, "\n")
+	trailer := key + ": " + value
+	paragraphs := strings.Split(message, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	if len(paragraphs) > 1 && isTrailerBlock(last) {
+		if strings.Contains("\n"+last+"\n", "\n"+trailer+"\n") {
+			return message + "\n"
+		}
+		return message + "\n" + trailer + "\n"
+	}
+	return message + "\n\n" + trailer + "\n"
+}
+
+// isTrailerBlock reports whether every line of a paragraph is a "Key: value" trailer
+func isTrailerBlock(paragraph string) bool {
+	for _, line := range strings.Split(paragraph, "\n") {
+		key, _, ok := strings.Cut(line, ": ")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return false
+		}
+	}
+	return true
+}
+
+// GitUserIdentity returns the "Name <email>" identity configured in git for
+// the user running kratt
+func GitUserIdentity(ctx context.Context) (string, error) {
+	values := []string{}
+	for _, key := range []string{"user.name", "user.email"} {
+		output, err := exec.CommandContext(ctx, "git", "config", key).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read git %s: %w", key, err)
+		}
+		values = append(values, strings.TrimSpace(string(output)))
+	}
+	return fmt.Sprintf("%s <%s>", values[0], values[1]), nil
+}
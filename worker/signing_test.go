@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendTrailer(t *testing.T) {
+	tests := []struct {
+		message, want string
+	}{
+		{"Fix parser", "Fix parser\n\nSigned-off-by: bot <bot@example.com>\n"},
+		{"fix: parser\n\nKratt-Run: r1\n", "fix: parser\n\nKratt-Run: r1\nSigned-off-by: bot <bot@example.com>\n"},
+		{"fix: parser\n\nEmpty input is handled: no panic", "fix: parser\n\nEmpty input is handled: no panic\n\nSigned-off-by: bot <bot@example.com>\n"},
+		{"fix: parser\n\nSigned-off-by: bot <bot@example.com>", "fix: parser\n\nSigned-off-by: bot <bot@example.com>\n"},
+	}
+	for _, tt := range tests {
+		if got := appendTrailer(tt.message, "Signed-off-by", "bot <bot@example.com>"); got != tt.want {
+			t.Errorf("appendTrailer(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestCommitSettingsValidate(t *testing.T) {
+	valid := []CommitSettings{
+		{},
+		{AuthorName: "kratt[bot]", AuthorEmail: "bot@example.com", SignOff: true},
+		{SigningFormat: SigningSSH, SigningKey: "~/.ssh/id_ed25519"},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", s, err)
+		}
+	}
+	invalid := []CommitSettings{
+		{AuthorName: "kratt[bot]"},
+		{CommitterEmail: "bot@example.com"},
+		{SigningFormat: SigningGPG},
+		{SigningKey: "ABCDEF"},
+		{SigningFormat: "x509", SigningKey: "key"},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Expected Validate(%+v) to fail", s)
+		}
+	}
+}
+
+func TestGoGitCommitIdentity(t *testing.T) {
+	ctx := context.Background()
+	g := newMemoryTestGit(t)
+	g.Commits = CommitSettings{AuthorName: "kratt[bot]", AuthorEmail: "bot@example.com", CommitterName: "ci", CommitterEmail: "ci@example.com", SignOff: true}
+
+	g.WriteFile(ctx, "notes.txt", "x\n")
+	if committed, err := g.Commit(ctx, "docs: add notes\n\nKratt-Run: r1\n"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+	head, _ := g.Repository().Head()
+	commit, err := g.Repository().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Author.Name != "kratt[bot]" || commit.Committer.Email != "ci@example.com" {
+		t.Errorf("Unexpected author %v and committer %v", commit.Author, commit.Committer)
+	}
+	if want := "docs: add notes\n\nKratt-Run: r1\nSigned-off-by: ci <ci@example.com>\n"; commit.Message != want {
+		t.Errorf("Message = %q, want %q", commit.Message, want)
+	}
+}
+
+func TestGitRunnerSignedCommit(t *testing.T) {
+	requireGit(t)
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	ctx := context.Background()
+	tmp := t.TempDir()
+	key := filepath.Join(tmp, "kratt_ed25519")
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %v: %s", err, output)
+	}
+	repo := filepath.Join(tmp, "repo")
+	if err := os.Mkdir(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initEvalRepo(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("x\n"), 0644)
+
+	original, _ := os.Getwd()
+	defer os.Chdir(original)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &GitRunner{Commits: CommitSettings{
+		AuthorName: "kratt[bot]", AuthorEmail: "bot@example.com",
+		SigningFormat: SigningSSH, SigningKey: key,
+		SignOff: true,
+	}}
+	if committed, err := runner.Commit(ctx, "docs: add notes"); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v", committed, err)
+	}
+
+	output, err := exec.Command("git", "cat-file", "commit", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := string(output)
+	for _, want := range []string{"author kratt[bot] <bot@example.com>", "committer kratt[bot] <bot@example.com>", "-----BEGIN SSH SIGNATURE-----", "Signed-off-by: kratt[bot] <bot@example.com>"} {
+		if !strings.Contains(commit, want) {
+			t.Errorf("Expected commit to contain %q, got:\n%s", want, commit)
+		}
+	}
+}
@@ -2,9 +2,13 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -16,83 +20,482 @@ type Worker struct {
 	TestCommand  []string      // Command to run tests
 	Deadline     time.Duration // Maximum time for agent execution
 
+	// Timeouts overrides Deadline for individual phases
+	Timeouts PhaseTimeouts
+
+	// Agent runs the AI agent; when nil, AgentCommand is run with the prompt on stdin
+	Agent Agent
+
+	// ResultProtocol asks the agent to write a structured result file that is
+	// rendered into the results comment
+	ResultProtocol bool
+
+	// Agents is the ordered fallback chain of agent profiles; it takes
+	// precedence over Agent and AgentCommand when set
+	Agents []AgentProfile
+	// Routes select a sub-chain of Agents by PR label, diff size or task type;
+	// the first matching route wins
+	Routes []AgentRoute
+	// TaskType is the kind of work requested (review, implement, fix-tests)
+	TaskType string
+
+	// Runs records run history; when nil, runs are not recorded
+	Runs RunStore
+
+	// Policy restricts the changes that may be committed; when nil, all changes are committed
+	Policy *Policy
+	// Budget limits the number of plan steps executed per run
+	Budget PlanBudget
+
+	// ResetWorktrees resets the PR branch's worktree to the remote branch before each run
+	ResetWorktrees bool
+
+	// DirtyWorktree is the policy for worktrees left dirty by an earlier run:
+	// DirtyAbort (default), DirtyStash or DirtyReset
+	DirtyWorktree string
+
+	// MirrorDir holds the bare repository mirrors used by ProcessRemotePR;
+	// empty means DefaultMirrorDir
+	MirrorDir string
+
+	// RequireApproval commits changes locally and waits for ApproveRun or
+	// RejectRun before pushing; requires Runs
+	RequireApproval bool
+
+	// CommitTemplate renders commit messages from a CommitMessage; when nil,
+	// DefaultCommitTemplate is used
+	CommitTemplate *template.Template
+	// SummarizeDiff asks the agent for a commit message describing the
+	// staged diff when it did not report a summary
+	SummarizeDiff bool
+	// AgentCommits decides what happens to commits the agent created itself:
+	// AgentCommitsPreserve (default) or AgentCommitsSquash
+	AgentCommits string
+	// CoAuthor is credited with a Co-authored-by trailer on the agent's
+	// commits, as "Name <email>"; usually the human who triggered the run
+	CoAuthor string
+
+	// SecretScanner blocks pushing changes that contain possible secrets; when nil, changes are not scanned
+	SecretScanner *SecretScanner
+	// Redactor masks secrets in commit messages, which are pushed along with
+	// the changes, and in run history; when nil, only the built-in token
+	// patterns are masked
+	Redactor *Redactor
+
 	// Dependencies (injected for testability)
 	Git    LocalGit
 	GitHub GitHub
 	Runner CommandRunner
+
+	// AgentRunner runs the agent, e.g. a SandboxRunner; when nil, Runner is used
+	AgentRunner CommandRunner
+	// CheckRunner runs lint and test commands; when nil, Runner is used
+	CheckRunner CommandRunner
+
+	// repo is the owner/repo of a run started by ProcessRemotePR
+	repo string
 }
 
 // ProcessPR processes a pull request by running the agent and posting results
-func (w *Worker) ProcessPR(prNumber int) error {
-	// 3.1: Get PR Information
-	prInfo, err := w.GitHub.GetPRInfo(prNumber)
+func (w *Worker) ProcessPR(ctx context.Context, prNumber int) error {
+	run, err := w.startRun(prNumber)
+	if err != nil {
+		return err
+	}
+	return w.finishRun(ctx, run, w.processPR(ctx, run, prNumber))
+}
+
+// startRun creates and records a new run for a pull request
+func (w *Worker) startRun(prNumber int) (*RunRecord, error) {
+	run := &RunRecord{
+		ID:        newRunID(prNumber, time.Now()),
+		Repo:      w.repo,
+		PRNumber:  prNumber,
+		TaskType:  w.TaskType,
+		Status:    RunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := w.saveRun(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// finishRun records the outcome of a run and returns the run's error.
+// A cancelled run is recorded as such and a short note is posted to the PR.
+func (w *Worker) finishRun(ctx context.Context, run *RunRecord, err error) error {
+	run.FinishedAt = time.Now()
+	if run.Status == RunStatusRunning {
+		run.Status = RunStatusSucceeded
+	}
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	}
+
+	if errors.Is(err, context.Canceled) {
+		run.Status = RunStatusCancelled
+
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		if commentErr := w.GitHub.PostComment(cleanupCtx, run.PRNumber, formatCancelledComment(run)); commentErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to post cancellation comment: %w", commentErr))
+		}
+	}
+
+	if saveErr := w.saveRun(run); saveErr != nil {
+		err = errors.Join(err, saveErr)
+	}
+	return err
+}
+
+// saveRun records the run in run history if a run store is configured.
+// Errors and agent output are redacted first, as they would be in a comment.
+func (w *Worker) saveRun(run *RunRecord) error {
+	if w.Runs == nil {
+		return nil
+	}
+	w.redactor().redactRun(run)
+	if err := w.Runs.SaveRun(run); err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	return nil
+}
+
+// processPR runs the steps of ProcessPR, filling in the run record
+func (w *Worker) processPR(ctx context.Context, run *RunRecord, prNumber int) error {
+	err := w.processPRPhases(ctx, run, prNumber)
+
+	var dirtyErr *DirtyWorktreeError
+	if errors.As(err, &dirtyErr) {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		if commentErr := w.GitHub.PostComment(cleanupCtx, prNumber, formatDirtyWorktreeComment(dirtyErr)); commentErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to post dirty worktree comment: %w", commentErr))
+		}
+		return err
+	}
+
+	var timeoutErr *PhaseTimeoutError
+	if errors.As(err, &timeoutErr) && timeoutErr.Phase != PhaseLint && timeoutErr.Phase != PhaseTest {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		if commentErr := w.GitHub.PostComment(cleanupCtx, prNumber, formatTimeoutComment(timeoutErr)); commentErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to post timeout comment: %w", commentErr))
+		}
+	}
+	return err
+}
+
+// processPRPhases runs each phase of ProcessPR under its own deadline
+func (w *Worker) processPRPhases(ctx context.Context, run *RunRecord, prNumber int) error {
+	var prInfo string
+	err := w.runPhase(ctx, PhaseSetup, func(ctx context.Context) error {
+		// 3.1: Get PR Information
+		var err error
+		prInfo, err = w.GitHub.GetPRInfo(ctx, prNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get PR info: %w", err)
+		}
+
+		// 3.2: Handle Git Worktree
+		branch, err := w.extractBranchFromPRInfo(prInfo)
+		if err != nil {
+			return fmt.Errorf("failed to extract branch from PR info: %w", err)
+		}
+		run.Branch = branch
+
+		return w.enterWorktree(ctx, run)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 3.3: Generate Agent Prompt
+	prompt := w.generatePrompt(prInfo)
+
+	// 3.4: Execute Agent with Timeout
+	chain, route, err := w.selectAgents(parsePRDetails(prInfo))
+	if err != nil {
+		return fmt.Errorf("failed to select agent: %w", err)
+	}
+	run.Route = route
+
+	run.BaseSHA, err = w.Git.HeadSHA(ctx)
+	if err != nil {
+		return err
+	}
+
+	var agentResult *AgentResult
+	err = w.runPhase(ctx, PhaseAgent, func(ctx context.Context) error {
+		var err error
+		agentResult, err = w.runAgentChain(ctx, run, chain, prompt)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run agent: %w", err)
+	}
+
+	// 3.5: Run Lint and Test Commands
+	lintOutput, lintErr := w.runCheck(ctx, PhaseLint, w.LintCommand)
+	testOutput, testErr := w.runCheck(ctx, PhaseTest, w.TestCommand)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// The message is produced before checking the changes, so that anything
+	// the agent writes while summarizing them is checked as well
+	message, err := w.commitMessage(ctx, run, findProfile(chain, run.Agent), agentResult)
+	if err != nil {
+		return err
+	}
+
+	commits, violations, findings, err := w.checkAgentWork(ctx, run.BaseSHA)
+	if err != nil {
+		return err
+	}
+	run.AgentCommits = commits
+	run.SecretFindings = findings
+
+	// 3.6: Push Changes. Blocked changes and runs awaiting approval are not
+	// pushed; otherwise the push comes first so that the results comment can
+	// link the agent's commits on GitHub
+	blocked := changesError(violations, findings)
+	push := blocked == nil && !w.RequireApproval
+	var pushErr error
+	if push {
+		pushErr = w.runPhase(ctx, PhasePush, func(ctx context.Context) error {
+			if err := w.pushAgentWork(ctx, run.BaseSHA, commits, message); err != nil {
+				return err
+			}
+			var err error
+			run.CommitSHA, err = w.Git.HeadSHA(ctx)
+			return err
+		})
+	}
+
+	// 3.7: Post Results Comment
+	commentBody := w.formatResultsComment(run, agentResult, lintOutput, lintErr, testOutput, testErr)
+	var linkErr error
+	if len(commits) > 0 {
+		agentCommits := w.formatAgentCommits(commits)
+		if push && pushErr == nil && w.AgentCommits != AgentCommitsSquash {
+			var linked string
+			if linked, linkErr = w.formatPushedAgentCommits(ctx, commits); linkErr == nil {
+				agentCommits = linked
+			}
+		}
+		commentBody += "\n" + agentCommits
+	}
+	if len(violations) > 0 {
+		commentBody += "\n" + formatPolicyViolations(violations)
+	}
+	if len(findings) > 0 {
+		commentBody += "\n" + formatSecretFindings(findings)
+	}
+	err = w.GitHub.PostComment(ctx, prNumber, commentBody)
 	if err != nil {
-		return fmt.Errorf("failed to get PR info: %w", err)
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+
+	if blocked != nil {
+		return blocked
 	}
+	if pushErr != nil {
+		return fmt.Errorf("failed to commit and push: %w", pushErr)
+	}
+	if w.RequireApproval {
+		err := w.runPhase(ctx, PhasePush, func(ctx context.Context) error {
+			return w.requestApproval(ctx, run, commits, message)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to commit and push: %w", err)
+		}
+	}
+	return linkErr
+}
 
-	// 3.2: Handle Git Worktree
-	branch, err := w.extractBranchFromPRInfo(prInfo)
+// redactor returns Redactor, or one masking only the built-in token patterns if none is set
+func (w *Worker) redactor() *Redactor {
+	if w.Redactor == nil {
+		return NewRedactor()
+	}
+	return w.Redactor
+}
+
+// runCheck runs a lint or test command under the phase's deadline
+func (w *Worker) runCheck(ctx context.Context, phase string, command []string) ([]byte, error) {
+	ctx, cancel := w.phaseContext(ctx, phase)
+	defer cancel()
+
+	output, err := w.checkRunner().RunWithOutput(ctx, command[0], command[1:]...)
+	return output, w.phaseError(phase, ctx, err)
+}
+
+// checkChanges stages all changes and checks their diff against base with the
+// policy and the secret scanner
+func (w *Worker) checkChanges(ctx context.Context, base string) ([]PolicyViolation, []SecretFinding, error) {
+	if w.Policy == nil && w.SecretScanner == nil {
+		return nil, nil, nil
+	}
+
+	if err := w.Git.StageAll(ctx); err != nil {
+		return nil, nil, err
+	}
+	diff, err := w.Git.StagedDiff(ctx, base)
 	if err != nil {
-		return fmt.Errorf("failed to extract branch from PR info: %w", err)
+		return nil, nil, err
+	}
+
+	var violations []PolicyViolation
+	if w.Policy != nil {
+		violations = w.Policy.Evaluate(diff)
 	}
+	var findings []SecretFinding
+	if w.SecretScanner != nil {
+		findings = w.SecretScanner.Scan(diff)
+	}
+	return violations, findings, nil
+}
+
+// changesError returns the error that prevents committing changes with
+// policy violations or possible secrets, or nil if there are none
+func changesError(violations []PolicyViolation, findings []SecretFinding) error {
+	var errs []error
+	if len(violations) > 0 {
+		errs = append(errs, &PolicyError{Violations: violations})
+	}
+	if len(findings) > 0 {
+		errs = append(errs, &SecretsFoundError{Findings: findings})
+	}
+	return errors.Join(errs...)
+}
+
+// agentRunner returns the runner used for agents
+func (w *Worker) agentRunner() CommandRunner {
+	if w.AgentRunner != nil {
+		return w.AgentRunner
+	}
+	return w.Runner
+}
+
+// checkRunner returns the runner used for lint and test commands
+func (w *Worker) checkRunner() CommandRunner {
+	if w.CheckRunner != nil {
+		return w.CheckRunner
+	}
+	return w.Runner
+}
 
-	exists, err := w.Git.CheckWorktreeExists(branch)
+// enterWorktree creates the worktree for the run's branch if needed, changes
+// into it and records it in the run. A worktree holding a run that awaits
+// approval is refused. With ResetWorktrees the branch is reset to its remote
+// state first; the worktree is then checked for leftovers of earlier runs (see
+// checkWorktree). A worktree left half-created by cancellation is removed again.
+func (w *Worker) enterWorktree(ctx context.Context, run *RunRecord) error {
+	branch := run.Branch
+	exists, err := w.Git.CheckWorktreeExists(ctx, branch)
 	if err != nil {
 		return fmt.Errorf("failed to check worktree existence: %w", err)
 	}
 
 	if !exists {
-		path, err := w.Git.GetWorktreePath(branch)
+		path, err := w.Git.GetWorktreePath(ctx, branch)
 		if err != nil {
 			return fmt.Errorf("failed to get worktree path: %w", err)
 		}
 
-		err = w.Git.CreateWorktree(branch, path)
+		err = w.Git.CreateWorktree(ctx, branch, path)
 		if err != nil {
+			if ctx.Err() != nil {
+				cleanupCtx, cancel := cleanupContext(ctx)
+				defer cancel()
+				w.Git.RemoveWorktree(cleanupCtx, path)
+				return fmt.Errorf("failed to create worktree: %w", ctx.Err())
+			}
 			return fmt.Errorf("failed to create worktree: %w", err)
 		}
+		run.WorktreeCreated = true
 	}
 
-	path, err := w.Git.GetWorktreePath(branch)
+	path, err := w.Git.GetWorktreePath(ctx, branch)
 	if err != nil {
 		return fmt.Errorf("failed to get worktree path: %w", err)
 	}
 
-	err = w.Git.ChangeDirectory(path)
+	if err := w.checkAwaitingApproval(path); err != nil {
+		return err
+	}
+
+	err = w.Git.ChangeDirectory(ctx, path)
 	if err != nil {
 		return fmt.Errorf("failed to change directory: %w", err)
 	}
+	run.Worktree = path
 
-	// 3.3: Generate Agent Prompt
-	prompt := w.generatePrompt(prInfo)
+	if w.ResetWorktrees {
+		if err := w.resetToRemote(ctx, branch); err != nil {
+			return err
+		}
+	} else if err := w.Git.FetchBranch(ctx, branch); err != nil {
+		return fmt.Errorf("failed to fetch branch: %w", err)
+	}
+	return w.checkWorktree(ctx, run)
+}
 
-	// 3.4: Execute Agent with Timeout
-	ctx, cancel := context.WithTimeout(context.Background(), w.Deadline)
-	defer cancel()
+// resetToRemote resets the current worktree to the remote state of branch
+func (w *Worker) resetToRemote(ctx context.Context, branch string) error {
+	if err := w.Git.FetchBranch(ctx, branch); err != nil {
+		return fmt.Errorf("failed to fetch branch: %w", err)
+	}
+	if err := w.Git.ResetHard(ctx, "origin/"+branch); err != nil {
+		return fmt.Errorf("failed to reset worktree: %w", err)
+	}
+	return nil
+}
 
-	err = w.Runner.RunWithStdin(ctx, prompt, w.AgentCommand[0], w.AgentCommand[1:]...)
-	if err != nil {
-		return fmt.Errorf("failed to run agent: %w", err)
+// agent returns the configured agent, falling back to AgentCommand fed via stdin
+func (w *Worker) agent() Agent {
+	if w.Agent != nil {
+		return w.Agent
 	}
+	return &StdinAgent{Command: w.AgentCommand}
+}
 
-	// 3.5: Run Lint and Test Commands
-	lintOutput, lintErr := w.Runner.RunWithOutput(ctx, w.LintCommand[0], w.LintCommand[1:]...)
-	testOutput, testErr := w.Runner.RunWithOutput(ctx, w.TestCommand[0], w.TestCommand[1:]...)
+// runAgent runs the agent on the prompt and collects its structured result.
+// With ResultProtocol enabled, a result file written by the agent takes
+// precedence over a result extracted from the agent's output.
+func (w *Worker) runAgent(ctx context.Context, agent Agent, prompt string) (*AgentResult, error) {
+	if !w.ResultProtocol {
+		return agent.Run(ctx, w.agentRunner(), prompt)
+	}
 
-	// 3.6: Post Results Comment
-	commentBody := w.formatResultsComment(lintOutput, lintErr, testOutput, testErr)
-	err = w.GitHub.PostComment(prNumber, commentBody)
+	scratch, err := scratchDir()
 	if err != nil {
-		return fmt.Errorf("failed to post comment: %w", err)
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(scratch, "result-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create result directory: %w", err)
 	}
+	defer os.RemoveAll(dir)
+
+	resultPath := filepath.Join(dir, "result.json")
+	prompt = prompt + "\n\n" + resultProtocolInstructions(resultPath)
 
-	// 3.7: Commit and Push Changes
-	err = w.Git.CommitAndPush("Automated changes from kratt worker")
+	result, err := agent.Run(ctx, w.agentRunner(), prompt)
 	if err != nil {
-		return fmt.Errorf("failed to commit and push: %w", err)
+		return nil, err
 	}
 
-	return nil
+	fileResult, err := readAgentResult(resultPath)
+	if err != nil {
+		return nil, err
+	}
+	if fileResult != nil {
+		return fileResult, nil
+	}
+	return result, nil
 }
 
 // extractBranchFromPRInfo extracts the branch name from PR information
@@ -132,22 +535,27 @@ func (w *Worker) generatePrompt(prInfo string) string {
 	return prompt.String()
 }
 
-// formatResultsComment formats the lint and test results into a comment
-func (w *Worker) formatResultsComment(lintOutput []byte, lintErr error, testOutput []byte, testErr error) string {
+// formatResultsComment formats the agent result and the lint and test results into a comment
+func (w *Worker) formatResultsComment(run *RunRecord, agentResult *AgentResult, lintOutput []byte, lintErr error, testOutput []byte, testErr error) string {
 	var comment strings.Builder
 
 	comment.WriteString("## Kratt Worker Results\n\n")
 
+	comment.WriteString(formatAgentAttempts(run))
+	comment.WriteString("\n")
+
+	if len(run.Recovered) > 0 {
+		fmt.Fprintf(&comment, "🧹 **Recovered worktree** (%s): %s\n\n", w.DirtyWorktree, strings.Join(run.Recovered, ", "))
+	}
+
+	if agentResult != nil {
+		comment.WriteString(formatAgentResult(agentResult))
+		comment.WriteString("\n")
+	}
+
 	// Lint results
 	comment.WriteString("### Lint Results\n")
-	if lintErr != nil {
-		comment.WriteString("❌ **Failed**\n")
-		comment.WriteString("```\n")
-		comment.WriteString(lintErr.Error())
-		comment.WriteString("\n```\n")
-	} else {
-		comment.WriteString("✅ **Passed**\n")
-	}
+	comment.WriteString(formatCheckStatus(lintErr))
 
 	if len(lintOutput) > 0 {
 		comment.WriteString("```\n")
@@ -159,14 +567,7 @@ func (w *Worker) formatResultsComment(lintOutput []byte, lintErr error, testOutp
 
 	// Test results
 	comment.WriteString("### Test Results\n")
-	if testErr != nil {
-		comment.WriteString("❌ **Failed**\n")
-		comment.WriteString("```\n")
-		comment.WriteString(testErr.Error())
-		comment.WriteString("\n```\n")
-	} else {
-		comment.WriteString("✅ **Passed**\n")
-	}
+	comment.WriteString(formatCheckStatus(testErr))
 
 	if len(testOutput) > 0 {
 		comment.WriteString("```\n")
@@ -177,40 +578,158 @@ func (w *Worker) formatResultsComment(lintOutput []byte, lintErr error, testOutp
 	return comment.String()
 }
 
+// formatCheckStatus formats the outcome of a lint or test command
+func formatCheckStatus(err error) string {
+	var timeoutErr *PhaseTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return fmt.Sprintf("⏱️ **Timed out** after %s\n", timeoutErr.Timeout)
+	}
+	if err != nil {
+		return "❌ **Failed**\n```\n" + err.Error() + "\n```\n"
+	}
+	return "✅ **Passed**\n"
+}
+
+// formatAgentResult formats the structured agent result as a comment section
+func formatAgentResult(result *AgentResult) string {
+	var section strings.Builder
+
+	section.WriteString("### Agent Summary\n")
+	if result.Summary != "" {
+		section.WriteString(result.Summary)
+		section.WriteString("\n")
+	}
+
+	if result.Confidence != "" {
+		section.WriteString("\n**Confidence:** ")
+		section.WriteString(result.Confidence)
+		section.WriteString("\n")
+	}
+
+	if len(result.FilesTouched) > 0 {
+		section.WriteString("\n**Files touched:**\n")
+		for _, file := range result.FilesTouched {
+			section.WriteString("- `")
+			section.WriteString(file)
+			section.WriteString("`\n")
+		}
+	}
+
+	if len(result.FollowUpQuestions) > 0 {
+		section.WriteString("\n**Follow-up questions:**\n")
+		for _, question := range result.FollowUpQuestions {
+			section.WriteString("- ")
+			section.WriteString(question)
+			section.WriteString("\n")
+		}
+	}
+
+	return section.String()
+}
+
 // Start creates a new branch and pull request with instructions
-func (w *Worker) Start(branchName string, instruction string) error {
-	// 8.1: Create and Switch to New Branch
-	err := w.Git.CreateBranch(branchName)
+func (w *Worker) Start(ctx context.Context, branchName string, instruction string) error {
+	_, err := w.StartWith(ctx, StartTask{Branch: branchName, Instructions: instruction})
+	return err
+}
+
+// StartWith is Start with the title and pull request options of a task. It
+// returns the created pull request so that callers can process it right away.
+func (w *Worker) StartWith(ctx context.Context, task StartTask) (PullRequest, error) {
+	title := task.Title
+	if title == "" {
+		title = titleFromInstructions(task.Instructions, task.Branch)
+	}
+	return w.start(ctx, task, title, startDescription(task.Branch))
+}
+
+// start creates the task's branch from its base branch, or the remote default
+// branch, in the branch's worktree, commits the instructions file there and
+// opens the pull request. The caller's checkout is left untouched and the
+// worktree is kept for processing the pull request.
+func (w *Worker) start(ctx context.Context, task StartTask, title, description string) (PullRequest, error) {
+	branchName := task.Branch
+
+	// 8.1: Create the Branch in its Own Worktree
+	base := task.Base
+	if base == "" {
+		var err error
+		base, err = w.Git.DefaultBranch(ctx)
+		if err != nil {
+			return PullRequest{}, fmt.Errorf("failed to determine base branch: %w", err)
+		}
+	}
+	if err := w.Git.FetchBranch(ctx, base); err != nil {
+		return PullRequest{}, fmt.Errorf("failed to fetch base branch: %w", err)
+	}
+
+	path, err := w.Git.GetWorktreePath(ctx, branchName)
 	if err != nil {
-		return fmt.Errorf("failed to create branch: %w", err)
+		return PullRequest{}, fmt.Errorf("failed to get worktree path: %w", err)
+	}
+	original, err := w.Git.CurrentDirectory(ctx)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	err = w.Git.CreateBranch(ctx, branchName, "origin/"+base, path)
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("failed to create branch: %w", err)
+	}
+	defer func() {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		w.Git.ChangeDirectory(cleanupCtx, original)
+	}()
+	if err := w.Git.ChangeDirectory(ctx, path); err != nil {
+		return PullRequest{}, fmt.Errorf("failed to change directory: %w", err)
 	}
 
 	// 8.2: Write Instructions File
 	filePath := fmt.Sprintf("docs/%s-instructions.md", branchName)
-	err = w.Git.WriteFile(filePath, instruction)
+	err = w.Git.WriteFile(ctx, filePath, task.Instructions)
 	if err != nil {
-		return fmt.Errorf("failed to write instructions file: %w", err)
+		return PullRequest{}, fmt.Errorf("failed to write instructions file: %w", err)
 	}
 
 	// 8.3: Commit Instructions File
-	err = w.Git.CommitAndPush("Add instructions for " + branchName)
+	err = w.Git.CommitAndPush(ctx, "Add instructions for "+branchName)
 	if err != nil {
-		return fmt.Errorf("failed to commit instructions file: %w", err)
+		return PullRequest{}, fmt.Errorf("failed to commit instructions file: %w", err)
 	}
 
 	// 8.4: Push Branch Upstream
-	err = w.Git.PushBranchUpstream(branchName)
+	err = w.Git.PushBranchUpstream(ctx, branchName)
 	if err != nil {
-		return fmt.Errorf("failed to push branch upstream: %w", err)
+		return PullRequest{}, fmt.Errorf("failed to push branch upstream: %w", err)
 	}
 
 	// 8.5: Create Pull Request
-	title := "Implement " + branchName
-	description := fmt.Sprintf("Study docs/%s-instructions.md and make a list of necessary implementation steps in docs/%s-implementation-status.md", branchName, branchName)
-	err = w.GitHub.CreatePR(title, description)
+	pr, err := w.GitHub.CreatePR(ctx, title, description, task.PROptions)
 	if err != nil {
-		return fmt.Errorf("failed to create pull request: %w", err)
+		return PullRequest{}, fmt.Errorf("failed to create pull request: %w", err)
 	}
 
-	return nil
+	return pr, nil
+}
+
+// maxTitleLength keeps derived pull request titles readable in lists
+const maxTitleLength = 72
+
+// titleFromInstructions uses the first line of the instructions as the pull
+// request title, shortened to maxTitleLength at a word boundary
+func titleFromInstructions(instructions, branchName string) string {
+	for _, line := range strings.Split(instructions, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#*->"))
+		if line == "" {
+			continue
+		}
+		return truncateAtWord(line, maxTitleLength)
+	}
+	return "Implement " + branchName
+}
+
+// startDescription is the pull request description asking the agent to plan the work
+func startDescription(branchName string) string {
+	return fmt.Sprintf("Study docs/%s-instructions.md and make a list of necessary implementation steps in %s", branchName, implementationStatusPath(branchName))
 }
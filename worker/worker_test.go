@@ -2,11 +2,45 @@ package worker
 
 import (
 	"context"
+	"fmt"
+	"os/exec"
 	"strings"
 	"testing"
 	"time"
 )
 
+// requireGit skips tests that run the git binary where it is not installed
+func requireGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+}
+
+// newTestWorker returns a worker for PR prNumber on branch that runs against
+// fakeGit and fakeGitHub with passing lint and test commands and an empty run
+// history; options adjust the worker before it is returned
+func newTestWorker(fakeGit *FakeLocalGit, fakeGitHub *FakeGitHub, prNumber int, branch string, options ...func(*Worker)) *Worker {
+	fakeGitHub.SetPRInfo(prNumber, fmt.Sprintf(`{"title": "Test PR", "body": "", "headRefName": %q, "comments": []}`, branch))
+	fakeRunner := NewFakeCommandRunner()
+	fakeRunner.SetResponse("goimports -w ./...", []byte(""), nil)
+	fakeRunner.SetResponse("go test ./...", []byte("PASS"), nil)
+	w := &Worker{
+		AgentCommand: []string{"echo", "agent-output"},
+		LintCommand:  []string{"goimports", "-w", "./..."},
+		TestCommand:  []string{"go", "test", "./..."},
+		Deadline:     5 * time.Second,
+		Runs:         NewFakeRunStore(),
+		Git:          fakeGit,
+		GitHub:       fakeGitHub,
+		Runner:       fakeRunner,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
 func TestWorkerProcessPR(t *testing.T) {
 	// Setup fakes
 	fakeGit := NewFakeLocalGit()
@@ -39,13 +73,13 @@ func TestWorkerProcessPR(t *testing.T) {
 	}
 
 	// Test ProcessPR
-	err := worker.ProcessPR(123)
+	err := worker.ProcessPR(context.Background(), 123)
 	if err != nil {
 		t.Fatalf("ProcessPR failed: %v", err)
 	}
 
 	// Verify worktree was created
-	exists, _ := fakeGit.CheckWorktreeExists("feature-branch")
+	exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "feature-branch")
 	if !exists {
 		t.Error("Expected worktree to be created for feature-branch")
 	}
@@ -67,23 +101,23 @@ func TestFakeLocalGit(t *testing.T) {
 	fake := NewFakeLocalGit()
 
 	// Test worktree operations
-	exists, err := fake.CheckWorktreeExists("test-branch")
+	exists, err := fake.CheckWorktreeExists(context.Background(), "test-branch")
 	if err != nil || exists {
 		t.Error("Expected worktree not to exist initially")
 	}
 
-	err = fake.CreateWorktree("test-branch", "/fake/path")
+	err = fake.CreateWorktree(context.Background(), "test-branch", "/fake/path")
 	if err != nil {
 		t.Fatalf("CreateWorktree failed: %v", err)
 	}
 
-	exists, err = fake.CheckWorktreeExists("test-branch")
+	exists, err = fake.CheckWorktreeExists(context.Background(), "test-branch")
 	if err != nil || !exists {
 		t.Error("Expected worktree to exist after creation")
 	}
 
 	// Test directory change
-	err = fake.ChangeDirectory("/new/path")
+	err = fake.ChangeDirectory(context.Background(), "/new/path")
 	if err != nil {
 		t.Fatalf("ChangeDirectory failed: %v", err)
 	}
@@ -93,7 +127,7 @@ func TestFakeLocalGit(t *testing.T) {
 	}
 
 	// Test commit
-	err = fake.CommitAndPush("test commit")
+	err = fake.CommitAndPush(context.Background(), "test commit")
 	if err != nil {
 		t.Fatalf("CommitAndPush failed: %v", err)
 	}
@@ -104,7 +138,7 @@ func TestFakeLocalGit(t *testing.T) {
 	}
 
 	// Test repository detection
-	isRepo, err := fake.IsGitRepository()
+	isRepo, err := fake.IsGitRepository(context.Background())
 	if err != nil {
 		t.Fatalf("IsGitRepository failed: %v", err)
 	}
@@ -113,7 +147,7 @@ func TestFakeLocalGit(t *testing.T) {
 	}
 
 	// Test GitHub repository detection
-	owner, repo, err := fake.GetGitHubRepository()
+	owner, repo, err := fake.GetGitHubRepository(context.Background())
 	if err != nil {
 		t.Fatalf("GetGitHubRepository failed: %v", err)
 	}
@@ -123,7 +157,7 @@ func TestFakeLocalGit(t *testing.T) {
 
 	// Test setting repository status
 	fake.SetGitRepository(false)
-	isRepo, err = fake.IsGitRepository()
+	isRepo, err = fake.IsGitRepository(context.Background())
 	if err != nil {
 		t.Fatalf("IsGitRepository failed: %v", err)
 	}
@@ -133,7 +167,7 @@ func TestFakeLocalGit(t *testing.T) {
 
 	// Test setting GitHub repository
 	fake.SetGitHubRepository("testowner", "testrepo")
-	owner, repo, err = fake.GetGitHubRepository()
+	owner, repo, err = fake.GetGitHubRepository(context.Background())
 	if err != nil {
 		t.Fatalf("GetGitHubRepository failed: %v", err)
 	}
@@ -146,19 +180,19 @@ func TestFakeGitHub(t *testing.T) {
 	fake := NewFakeGitHub()
 
 	// Test PR info
-	_, err := fake.GetPRInfo(123)
+	_, err := fake.GetPRInfo(context.Background(), 123)
 	if err == nil {
 		t.Error("Expected error when getting non-existent PR")
 	}
 
 	fake.SetPRInfo(123, "test pr info")
-	info, err := fake.GetPRInfo(123)
+	info, err := fake.GetPRInfo(context.Background(), 123)
 	if err != nil || info != "test pr info" {
 		t.Error("Expected to get stored PR info")
 	}
 
 	// Test comments
-	err = fake.PostComment(123, "test comment")
+	err = fake.PostComment(context.Background(), 123, "test comment")
 	if err != nil {
 		t.Fatalf("PostComment failed: %v", err)
 	}
@@ -214,7 +248,7 @@ func TestWorkerStart(t *testing.T) {
 	instruction := "Implement user authentication with JWT tokens"
 
 	// Test Start method
-	err := worker.Start(branchName, instruction)
+	err := worker.Start(context.Background(), branchName, instruction)
 	if err != nil {
 		t.Fatalf("Start failed: %v", err)
 	}
@@ -275,7 +309,7 @@ func TestWorkerStart(t *testing.T) {
 		t.Fatalf("Expected 1 PR to be created, got %d", len(prs))
 	}
 
-	expectedTitle := "Implement " + branchName
+	expectedTitle := instruction
 	expectedDescription := "Study docs/feature/auth-system-instructions.md and make a list of necessary implementation steps in docs/feature/auth-system-implementation-status.md"
 
 	if prs[0].Title != expectedTitle {
@@ -353,7 +387,7 @@ func TestWorkerStartErrorHandling(t *testing.T) {
 			}
 
 			// Test Start method - should fail
-			err := worker.Start("test-branch", "test instruction")
+			err := worker.Start(context.Background(), "test-branch", "test instruction")
 			if err == nil {
 				t.Fatal("Expected Start to fail, but it succeeded")
 			}
@@ -364,3 +398,81 @@ func TestWorkerStartErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkerStartLeavesCheckoutUntouched(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.SetDefaultBranch("trunk")
+	w := &Worker{Git: fakeGit, GitHub: NewFakeGitHub(), Runner: NewFakeCommandRunner()}
+
+	if err := w.Start(context.Background(), "feature", "Build it"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if fetched := fakeGit.GetFetchedBranches(); len(fetched) != 1 || fetched[0] != "trunk" {
+		t.Errorf("Expected the default branch to be fetched, got %v", fetched)
+	}
+	if start := fakeGit.GetStartPoint("feature"); start != "origin/trunk" {
+		t.Errorf("Expected branch to start at origin/trunk, got %q", start)
+	}
+	if path, _ := fakeGit.GetWorktreePath(context.Background(), "feature"); path != "/fake/repo-feature" {
+		t.Errorf("Expected the branch to get its own worktree, got %q", path)
+	}
+	if dir := fakeGit.GetCurrentDir(); dir != "/fake/repo" {
+		t.Errorf("Expected to return to the original directory, got %q", dir)
+	}
+}
+
+func TestTitleFromInstructions(t *testing.T) {
+	tests := []struct {
+		instructions string
+		want         string
+	}{
+		{"Add rate limiting\n\nDetails follow.", "Add rate limiting"},
+		{"\n## Add a cache for PR info\n", "Add a cache for PR info"},
+		{"- Fix the flaky test", "Fix the flaky test"},
+		{"Rewrite the configuration loader so that it supports includes, environment overrides and defaults", "Rewrite the configuration loader so that it supports includes…"},
+		{strings.Repeat("ä", 80), strings.Repeat("ä", 71) + "…"},
+		{"  \n", "Implement feature"},
+	}
+
+	for _, tt := range tests {
+		if got := titleFromInstructions(tt.instructions, "feature"); got != tt.want {
+			t.Errorf("titleFromInstructions(%q) = %q, want %q", tt.instructions, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerStartWithOptions(t *testing.T) {
+	fakeGitHub := NewFakeGitHub()
+	w := &Worker{Git: NewFakeLocalGit(), GitHub: fakeGitHub, Runner: NewFakeCommandRunner()}
+
+	task := StartTask{
+		Branch:       "feature",
+		Instructions: "Build it",
+		Title:        "Build the feature",
+		PROptions:    PROptions{Draft: true, Reviewers: []string{"bob"}, Milestone: "v2"},
+	}
+	pr, err := w.StartWith(context.Background(), task)
+	if err != nil {
+		t.Fatalf("StartWith failed: %v", err)
+	}
+	if pr.Number != 1 || pr.URL != "https://github.com/fake/repo/pull/1" {
+		t.Errorf("Unexpected pull request: %+v", pr)
+	}
+
+	created := fakeGitHub.GetCreatedPRs()[0]
+	if created.Title != "Build the feature" || !created.Options.Draft || created.Options.Reviewers[0] != "bob" || created.Options.Milestone != "v2" {
+		t.Errorf("Unexpected created PR: %+v", created)
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	first, second := newRunID(42, now), newRunID(42, now.Add(time.Millisecond))
+	if first != "20250101-120000-000000000-pr42" {
+		t.Errorf("Unexpected run ID %s", first)
+	}
+	if first == second || first > second {
+		t.Errorf("Expected distinct, sortable IDs within a second, got %s and %s", first, second)
+	}
+}
@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WorktreeInfo describes a worktree of the repository together with the run
+// that created it and the state of its branch's pull request
+type WorktreeInfo struct {
+	Worktree
+	RunID     string    // Run that created the worktree, if any
+	PRNumber  int       // PR of the run that created the worktree
+	CreatedAt time.Time // Start of the run that created the worktree
+	PRState   string    // State of the branch's most recent PR, if looked up
+	Kept      string    // Why PruneWorktrees left the worktree in place, e.g. uncommitted changes
+}
+
+// ListWorktrees returns all worktrees except the main one, with the run that
+// created each of them taken from run history
+func (w *Worker) ListWorktrees(ctx context.Context) ([]WorktreeInfo, error) {
+	worktrees, err := w.Git.ListWorktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*RunRecord
+	if w.Runs != nil {
+		runs, err = w.Runs.ListRuns()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var infos []WorktreeInfo
+	for _, worktree := range worktrees {
+		if worktree.Main {
+			continue
+		}
+		info := WorktreeInfo{Worktree: worktree}
+		// Runs are oldest first; a path reused after pruning belongs to the latest run
+		for _, run := range runs {
+			if run.WorktreeCreated && run.Worktree == worktree.Path {
+				info.RunID = run.ID
+				info.PRNumber = run.PRNumber
+				info.CreatedAt = run.StartedAt
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// PruneWorktrees removes the worktrees created by recorded runs whose branch's
+// pull request was merged or closed and returns them. Worktrees with uncommitted
+// changes or an unfinished operation are kept, with the reason in Kept, unless
+// force is set. With dryRun nothing is removed.
+func (w *Worker) PruneWorktrees(ctx context.Context, dryRun, force bool) ([]WorktreeInfo, error) {
+	infos, err := w.ListWorktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []WorktreeInfo
+	for _, info := range infos {
+		// Only worktrees recorded as created by a run are kratt's to remove
+		if info.Branch == "" || info.RunID == "" {
+			continue
+		}
+		info.PRState, err = w.GitHub.PRState(ctx, info.Branch)
+		if err != nil {
+			return pruned, err
+		}
+		if info.PRState != PRStateMerged && info.PRState != PRStateClosed {
+			continue
+		}
+		if !force {
+			if info.Kept, err = w.unsavedWork(ctx, info); err != nil {
+				return pruned, err
+			}
+		}
+		if !dryRun && info.Kept == "" {
+			if err := w.Git.RemoveWorktree(ctx, info.Path); err != nil {
+				return pruned, err
+			}
+		}
+		pruned = append(pruned, info)
+	}
+	return pruned, nil
+}
+
+// unsavedWork describes the uncommitted changes or unfinished operation in a
+// worktree that removing it would lose, or returns "" if there are none
+func (w *Worker) unsavedWork(ctx context.Context, info WorktreeInfo) (string, error) {
+	original, err := w.Git.CurrentDirectory(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		w.Git.ChangeDirectory(cleanupCtx, original)
+	}()
+	if err := w.Git.ChangeDirectory(ctx, info.Path); err != nil {
+		return "", fmt.Errorf("failed to change directory: %w", err)
+	}
+
+	status, err := w.Git.Status(ctx, info.Branch)
+	switch {
+	case ctx.Err() != nil:
+		return "", ctx.Err()
+	case len(status.Changes) > 0:
+		return fmt.Sprintf("%d uncommitted changes", len(status.Changes)), nil
+	case status.Operation != "":
+		return status.Operation + " in progress", nil
+	case err != nil:
+		// Err on the side of keeping the worktree; --force removes it anyway
+		return fmt.Sprintf("could not check for uncommitted changes: %v", err), nil
+	}
+	return "", nil
+}
+
+// ResetWorktree resets the worktree of a branch to the remote branch,
+// discarding local commits and uncommitted changes to tracked files
+func (w *Worker) ResetWorktree(ctx context.Context, branch string) error {
+	exists, err := w.Git.CheckWorktreeExists(ctx, branch)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no worktree for branch %s", branch)
+	}
+
+	path, err := w.Git.GetWorktreePath(ctx, branch)
+	if err != nil {
+		return fmt.Errorf("failed to get worktree path: %w", err)
+	}
+	if err := w.checkAwaitingApproval(path); err != nil {
+		return err
+	}
+	original, err := w.Git.CurrentDirectory(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cleanupCtx, cancel := cleanupContext(ctx)
+		defer cancel()
+		w.Git.ChangeDirectory(cleanupCtx, original)
+	}()
+	if err := w.Git.ChangeDirectory(ctx, path); err != nil {
+		return fmt.Errorf("failed to change directory: %w", err)
+	}
+	return w.resetToRemote(ctx, branch)
+}
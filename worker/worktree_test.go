@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWorktreeList(t *testing.T) {
+	output := `worktree /src/repo
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /src/repo-feat-2
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feat-2
+
+worktree /src/repo-detached
+HEAD 3333333333333333333333333333333333333333
+detached
+`
+	worktrees := parseWorktreeList(output)
+	if len(worktrees) != 3 || !worktrees[0].Main || worktrees[1].Main {
+		t.Fatalf("Unexpected worktrees: %+v", worktrees)
+	}
+	if worktrees[1].Branch != "feat-2" || worktrees[1].Path != "/src/repo-feat-2" || worktrees[2].Branch != "" {
+		t.Errorf("Unexpected worktrees: %+v", worktrees)
+	}
+}
+
+func TestWorktreeDirName(t *testing.T) {
+	if got := worktreeDirName("kratt", "main"); got != "kratt-main" {
+		t.Errorf("Expected a plain branch name to be kept, got %s", got)
+	}
+	slashed, hyphenated := worktreeDirName("kratt", "feature/x"), worktreeDirName("kratt", "feature-x")
+	if !strings.HasPrefix(slashed, "kratt-feature-x-") || !strings.HasPrefix(hyphenated, "kratt-feature-x-") {
+		t.Errorf("Expected slashes to be replaced, got %s and %s", slashed, hyphenated)
+	}
+	if slashed == hyphenated {
+		t.Errorf("Expected feature/x and feature-x to get different directories, both got %s", slashed)
+	}
+}
+
+func TestWorktreePathBelowRoot(t *testing.T) {
+	paths := make(map[string]bool)
+	for _, mirror := range []string{"/mirrors/a/foo.git", "/mirrors/b/foo.git"} {
+		path, err := worktreePath([]Worktree{{Path: mirror, Main: true}}, "/worktrees", "main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths[path] = true
+	}
+	if !paths["/worktrees/a-foo-main"] || !paths["/worktrees/b-foo-main"] {
+		t.Errorf("Expected the owner in worktree names below a root, got %v", paths)
+	}
+
+	if path, _ := worktreePath([]Worktree{{Path: "/src/foo", Main: true}}, "", "main"); path != "/src/foo-main" {
+		t.Errorf("Expected worktree next to the repository without a root, got %s", path)
+	}
+}
+
+func TestWorkerListAndPruneWorktrees(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.CreateWorktree(context.Background(), "merged", "/fake/repo-merged")
+	fakeGit.CreateWorktree(context.Background(), "open", "/fake/repo-open")
+
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRState("merged", PRStateMerged)
+	fakeGitHub.SetPRState("open", PRStateOpen)
+
+	runs := NewFakeRunStore()
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	runs.SaveRun(&RunRecord{ID: "run-1", PRNumber: 3, StartedAt: started, Worktree: "/fake/repo-merged", WorktreeCreated: true})
+
+	w := &Worker{Git: fakeGit, GitHub: fakeGitHub, Runs: runs}
+
+	infos, err := w.ListWorktrees(context.Background())
+	if err != nil {
+		t.Fatalf("ListWorktrees failed: %v", err)
+	}
+	if len(infos) != 2 || infos[0].RunID != "run-1" || infos[0].PRNumber != 3 || !infos[0].CreatedAt.Equal(started) || infos[1].RunID != "" {
+		t.Errorf("Unexpected worktrees: %+v", infos)
+	}
+
+	pruned, err := w.PruneWorktrees(context.Background(), true, false)
+	if err != nil || len(pruned) != 1 || pruned[0].Branch != "merged" {
+		t.Fatalf("Expected dry run to report the merged worktree, got %+v (%v)", pruned, err)
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "merged"); !exists {
+		t.Error("Expected dry run to keep the worktree")
+	}
+
+	if _, err := w.PruneWorktrees(context.Background(), false, false); err != nil {
+		t.Fatalf("PruneWorktrees failed: %v", err)
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "merged"); exists {
+		t.Error("Expected the merged worktree to be removed")
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "open"); !exists {
+		t.Error("Expected the open worktree to be kept")
+	}
+}
+
+func TestWorkerPruneWorktreesKeepsUserWork(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	fakeGit.CreateWorktree(context.Background(), "dirty", "/fake/repo-dirty")
+	fakeGit.CreateWorktree(context.Background(), "manual", "/home/user/manual")
+	fakeGit.SetStatus(WorktreeStatus{Branch: "dirty", Changes: []string{" M main.go"}})
+
+	fakeGitHub := NewFakeGitHub()
+	fakeGitHub.SetPRState("dirty", PRStateMerged)
+	fakeGitHub.SetPRState("manual", PRStateMerged)
+	runs := NewFakeRunStore()
+	runs.SaveRun(&RunRecord{ID: "run-1", Worktree: "/fake/repo-dirty", WorktreeCreated: true})
+	w := &Worker{Git: fakeGit, GitHub: fakeGitHub, Runs: runs}
+
+	pruned, err := w.PruneWorktrees(context.Background(), false, false)
+	if err != nil || len(pruned) != 1 || pruned[0].Branch != "dirty" || pruned[0].Kept != "1 uncommitted changes" {
+		t.Fatalf("Expected only the dirty kratt worktree to be reported as kept, got %+v (%v)", pruned, err)
+	}
+	for _, branch := range []string{"dirty", "manual"} {
+		if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), branch); !exists {
+			t.Errorf("Expected worktree of %s to be kept", branch)
+		}
+	}
+
+	if _, err := w.PruneWorktrees(context.Background(), false, true); err != nil {
+		t.Fatalf("PruneWorktrees failed: %v", err)
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "dirty"); exists {
+		t.Error("Expected --force to remove the dirty worktree")
+	}
+	if exists, _ := fakeGit.CheckWorktreeExists(context.Background(), "manual"); !exists {
+		t.Error("Expected the worktree added by hand to be kept even with --force")
+	}
+}
+
+func TestWorkerEnterWorktreeReset(t *testing.T) {
+	fakeGit := NewFakeLocalGit()
+	w := &Worker{Git: fakeGit, ResetWorktrees: true}
+
+	run := &RunRecord{Branch: "feature"}
+	if err := w.enterWorktree(context.Background(), run); err != nil {
+		t.Fatalf("enterWorktree failed: %v", err)
+	}
+	if !run.WorktreeCreated || run.Worktree != "/fake/repo-feature" {
+		t.Errorf("Unexpected run record: %+v", run)
+	}
+	if fetched := fakeGit.GetFetchedBranches(); len(fetched) != 1 || fetched[0] != "feature" {
+		t.Errorf("Expected the branch to be fetched, got %v", fetched)
+	}
+	if resets := fakeGit.GetResets(); len(resets) != 1 || resets[0] != "origin/feature" {
+		t.Errorf("Expected a reset to origin/feature, got %v", resets)
+	}
+}